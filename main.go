@@ -1,23 +1,37 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
-	"math/rand"
 	"os"
+	"os/signal"
 	"time"
 
 	gc "github.com/clex/genetic-chess/src"
+	"github.com/clex/genetic-chess/src/chess"
 )
 
 func main() {
-	rand.Seed(time.Now().UTC().UnixNano())
 	l := log.New(os.Stderr, "", 0)
 
 	play := flag.Bool("play", false,
 		"play against ai")
+	uci := flag.Bool("uci", false,
+		"speak the universal chess interface on stdin/stdout")
+	xboard := flag.Bool("xboard", false,
+		"speak the chess engine communication protocol on stdin/stdout")
+	perft := flag.Uint("perft", 0,
+		"count the leaf nodes of the move tree of the -fen position "+
+			"up to the given depth")
 	file := flag.String("file", gc.DefaultFilePath,
 		"data file, created if necessary")
+	fen := flag.String("fen", chess.StartFEN,
+		"initial position of the games in FEN")
+	chess960 := flag.Bool("chess960", false,
+		"start each pair of tournament games from a random Chess960 "+
+			"position instead of -fen")
 	qualified := flag.Uint("qualified", 1,
 		"number of ai qualified for the next tournament")
 	games := flag.Uint("games", 2,
@@ -33,7 +47,18 @@ func main() {
 		"maximum time to think for a move "+
 			`(suffix with "ms", "s", "m" or "h"`)
 	maxDepth := flag.Uint("max-depth", 3,
-		"maximum allowed depth in games tree")
+		"maximum search depth in plies (0 means no limit)")
+	nodesPerMove := flag.Uint64("nodes-per-move", 0,
+		"maximum number of nodes searched for a move instead of "+
+			"-time-to-think, for reproducible games (0 means no limit)")
+	seed := flag.Int64("seed", 0,
+		"seed of the random mutations and Chess960 positions of the "+
+			"tournaments (0 means a random seed)")
+	hashSize := flag.Uint("hash", gc.DefaultHashSize,
+		"size of the transposition table of each ai in MB")
+	threads := flag.Uint("threads", 1,
+		"number of threads searching each move, sharing the "+
+			"transposition table")
 	parallelGames := flag.Uint("parallel-games", 1,
 		"number of parallel games (each game uses a go routine)")
 	rounds := flag.Uint("rounds", 0,
@@ -67,24 +92,69 @@ func main() {
 		l.Fatalf("expected -children to be "+
 			"a positive duration instead of %v", *timeToThink)
 	}
-	if *maxDepth == 0 {
-		l.Fatalf("expected -max-depth to be " +
+	if *hashSize == 0 {
+		l.Fatalf("expected -hash to be " +
 			"a positive integer instead of 0")
 	}
+	if *threads == 0 || *threads > gc.MaxThreads {
+		l.Fatalf("expected -threads to be "+
+			"between 1 and %d instead of %d", gc.MaxThreads, *threads)
+	}
 	if *parallelGames == 0 {
 		l.Fatalf("expected -parallel-games to be " +
 			"a positive integer instead of 0")
 	}
+	if *nodesPerMove > 0 && *threads > 1 {
+		l.Fatalf("expected -threads to be 1 with -nodes-per-move "+
+			"instead of %d", *threads)
+	}
+
+	if *seed == 0 {
+		*seed = time.Now().UTC().UnixNano()
+	}
+	gc.Seed(*seed)
 
-	if *play == true {
-		err := gc.Play(*file, *timeToThink, *maxDepth)
+	limits := gc.SearchLimits{
+		Clock: gc.Clock{MoveTime: *timeToThink},
+		Depth: *maxDepth,
+	}
+	if *nodesPerMove > 0 {
+		// The time to think depends on the load of the machine.
+		limits.Clock = gc.Clock{}
+		limits.Nodes = *nodesPerMove
+	}
+
+	if *perft > 0 {
+		err := chess.RunPerft(*fen, *perft)
+		if err != nil {
+			l.Fatalf("perft failed: %v", err)
+		}
+	} else if *uci == true {
+		err := gc.UCI(*file, limits, *hashSize, *threads)
+		if err != nil {
+			l.Fatalf("uci failed: %v", err)
+		}
+	} else if *xboard == true {
+		err := gc.XBoard(*file, limits, *hashSize, *threads)
+		if err != nil {
+			l.Fatalf("xboard failed: %v", err)
+		}
+	} else if *play == true {
+		err := gc.Play(*file, *fen, limits, *hashSize, *threads)
 		if err != nil {
 			l.Fatalf("cannot play: %v", err)
 		}
 	} else {
-		err := gc.RunTournaments(*file, *timeToThink, *maxDepth,
-			*qualified, *children, *games, *mutations, *mutationSize,
-			*parallelGames, *rounds, *quiet)
+		if !*quiet {
+			fmt.Printf("Seed: %d\n\n", *seed)
+		}
+
+		// Ctrl-C aborts the tournament in progress.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := gc.RunTournaments(ctx, *file, *fen, limits, *hashSize,
+			*threads, *qualified, *children, *games, *mutations,
+			*mutationSize, *parallelGames, *rounds, *chess960, *quiet)
+		stop()
 		if err != nil {
 			l.Fatalf("tournament failed: %v", err)
 		}
@@ -1,13 +1,16 @@
 package geneticchess
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math"
-	"math/rand"
 	"sort"
+	"sync"
 	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
 )
 
 const DefaultFilePath = "/tmp/genetic-chess-phenotype.json"
@@ -20,6 +23,13 @@ type AI struct {
 	Genes map[string]float64
 
 	tables *Tables `json:"-"`
+
+	hashSize uint
+	tt       *TranspositionTable
+	ttMu     sync.Mutex
+
+	// Number of threads searching each move.
+	threads uint
 }
 
 type boardInfoPiecesCount struct {
@@ -54,9 +64,20 @@ func NewAI() *AI {
 			"PiecePositionQueen":  1.0,
 			"PiecePositionKing":   0.1,
 			"NbMovesFactor":       0.01,
-			"PruneRatio":          0.5,
+			"PruneRatio":          0.0,
 			"MinKeptNodes":        5.0,
 			"EndgameNbPieces":     10.0,
+			"QuiescenceDepth":     4.0,
+			"DeltaMargin":         2.0,
+
+			// Disabled by default so that phenotypes saved before the gene
+			// was added keep the same evaluation.
+			"HangingPieces": 0.0,
+
+			"NullMoveReduction":    2.0,
+			"NullMoveVerification": 4.0,
+			"LMRMoveCount":         4.0,
+			"LMRReduction":         1.0,
 		},
 		tables: NewTables(),
 	}
@@ -87,7 +108,7 @@ func NewAIRandom() *AI {
 	ai.CloneID = ai.getRandID(idLen)
 
 	for _, gene := range genes {
-		ai.Genes[gene.name] = (gene.max - gene.min) * rand.Float64()
+		ai.Genes[gene.name] = (gene.max - gene.min) * rng.Float64()
 	}
 
 	return ai
@@ -102,6 +123,16 @@ func NewAIFromJSON(data []byte) (*AI, error) {
 
 	AI.tables = NewTables()
 
+	// Files saved before a gene was added use its default value.
+	if AI.Genes == nil {
+		AI.Genes = map[string]float64{}
+	}
+	for name, val := range NewAI().Genes {
+		if _, ok := AI.Genes[name]; !ok {
+			AI.Genes[name] = val
+		}
+	}
+
 	return AI, nil
 }
 
@@ -132,11 +163,23 @@ func (ai *AI) getGene(gene string) float64 {
 	return val
 }
 
+// pieceValue returns the material value of a piece type, the king being
+// worth more than any other piece.
+func (ai *AI) pieceValue(kind chess.PieceType) float64 {
+	if kind == chess.King {
+		return 100
+	}
+
+	return ai.getGene("PieceValue" + kind.GetName())
+}
+
 func (ai *AI) clone() *AI {
 	clone := &AI{
 		Genes:      make(map[string]float64),
 		tables:     ai.tables,
 		Generation: ai.Generation,
+		hashSize:   ai.hashSize,
+		threads:    ai.threads,
 	}
 
 	for key, val := range ai.Genes {
@@ -146,201 +189,131 @@ func (ai *AI) clone() *AI {
 	return clone
 }
 
-type Job struct {
-	node  *Node
-	board *Board
-}
-
-type PrunableNode struct {
-	node  *Node
-	board *Board
+// SetHashSize sets the size of the transposition table in MB, the table being
+// allocated on the next search.
+func (ai *AI) SetHashSize(sizeMB uint) {
+	ai.ttMu.Lock()
+	defer ai.ttMu.Unlock()
 
-	move Move
+	ai.hashSize = sizeMB
+	ai.tt = nil
 }
 
-type PrunableNodes []PrunableNode
+// withOwnHash returns a copy of the AI searching with its own transposition
+// table, so that its searches do not depend on the ones of other games played
+// by the AI. The hits of the copy are counted by mergeHash.
+func (ai *AI) withOwnHash() *AI {
+	clone := ai.clone()
+	clone.CloneID = ai.CloneID
 
-func (nm PrunableNodes) Len() int {
-	return len(nm)
+	return clone
 }
 
-func (nm PrunableNodes) Less(i int, j int) bool {
-	return nm[i].node.score <= nm[j].node.score
+// mergeHash counts the probes and hits of the transposition table of a copy
+// returned by withOwnHash in the hash hit rate of the AI.
+func (ai *AI) mergeHash(own *AI) {
+	if own.tt != nil {
+		ai.transpositionTable().addStats(own.tt)
+	}
 }
 
-func (nm PrunableNodes) Swap(i int, j int) {
-	nm[i], nm[j] = nm[j], nm[i]
+// SetThreads sets the number of threads searching each move, sharing the
+// transposition table. 0 and 1 both mean a single thread.
+func (ai *AI) SetThreads(threads uint) {
+	ai.threads = threads
 }
 
-func (ai *AI) buildNode(b *Board, move *Move, depth int) (*Node, State) {
-	state := b.Move(move)
-
-	switch state {
-	case StateBlackWins:
-		return &Node{score: -100000.0 + float64(depth), turn: b.turn}, state
-
-	case StateWhiteWins:
-		return &Node{score: 100000.0 - float64(depth), turn: b.turn}, state
+func (ai *AI) transpositionTable() *TranspositionTable {
+	ai.ttMu.Lock()
+	defer ai.ttMu.Unlock()
 
-	case StateDrawByRepetition:
-		fallthrough
-	case StateDrawByStalemate:
-		fallthrough
-	case StateDrawByInsufficientMaterial:
-		return &Node{score: 0, turn: b.turn}, state
+	if ai.tt == nil {
+		size := ai.hashSize
+		if size == 0 {
+			size = DefaultHashSize
+		}
 
-	case StatePlaying:
-		return &Node{
-			turn:     b.turn,
-			score:    ai.evalPosition(b),
-			children: make(map[Move]*Node),
-		}, state
+		ai.tt = NewTranspositionTable(size)
 	}
 
-	panic("unknown state: " + string(state))
-	return nil, 0
+	return ai.tt
 }
 
-func (ai *AI) pruneNodes(list PrunableNodes, turn Color,
-	pruneRatio float64, minKeptNodes uint) PrunableNodes {
-	if minKeptNodes == 0 {
-		// Keep at least one node.
-		minKeptNodes = 1
-	}
-
-	sort.Sort(list)
-
-	to := len(list)
-	from := int(float64(to) * pruneRatio)
-	if to-from < int(minKeptNodes) {
-		from = to - int(minKeptNodes)
-		if from < 0 {
-			from = 0
-		}
-	}
-	toKeep := to - from
-
-	if turn == White {
-		return list[from:]
-	}
-	return list[:toKeep]
+// HashHitRate returns the ratio of positions found in the transposition
+// table during the searches of this AI.
+func (ai *AI) HashHitRate() float64 {
+	return ai.transpositionTable().HitRate()
 }
 
-func (ai *AI) buildTree(b *Board, timeToThink time.Duration,
-	maxAllowedDepth uint, truncate bool) *Node {
-	end := time.Now().Add(timeToThink)
-	root := &Node{children: map[Move]*Node{}, turn: b.turn}
-	todo := []*Job{&Job{node: root, board: b}}
-	pruneRatio := ai.getGene("PruneRatio")
-	minKeptNodes := math.Floor(ai.getGene("MinKeptNodes") + 0.5)
-	maxDepth := 0
-
-loop:
-	for len(todo) > 0 {
-		job := todo[0]
-		todo = todo[1:]
-
-		var list PrunableNodes
-
-		depth := job.board.nbMoves - b.nbMoves
-		m := job.board.GetMoves()
-		for _, move := range m {
-			newBoard := job.board.clone()
-
-			if depth > maxDepth {
-				maxDepth = depth
-				if maxAllowedDepth > 0 &&
-					maxDepth > int(maxAllowedDepth) {
-					break loop
-				}
-			}
-
-			child, state := ai.buildNode(newBoard, &move, depth)
-			if state == StatePlaying {
-				list = append(list, PrunableNode{
-					move:  move,
-					node:  child,
-					board: newBoard,
-				})
-			} else {
-				job.node.children[move] = child
-			}
-		}
-
-		if len(list) > 0 {
-			list = ai.pruneNodes(list, job.board.turn,
-				pruneRatio, uint(minKeptNodes))
-			if len(list) == 0 {
-				panic("all moves were pruned")
-			}
-
-			for _, elem := range list {
-				job.node.children[elem.move] = elem.node
-				todo = append(todo, &Job{
-					node:  elem.node,
-					board: elem.board,
-				})
-			}
-		}
-
-		if timeToThink > 0 && time.Now().After(end) {
-			break loop
-		}
-	}
-
-	if truncate == true && maxDepth > 1 {
-		// Truncate level that could not be completely computed.
-		root.truncate(maxDepth - 1)
-	}
+// ClearHash empties the transposition table, for instance before a new game.
+func (ai *AI) ClearHash() {
+	ai.transpositionTable().Clear()
+}
 
-	return root
+// GetBestMoveScore returns the result of the search of the best move, given
+// a time to think and a maximum depth.
+func (ai *AI) GetBestMoveScore(b *chess.Board,
+	timeToThink time.Duration, maxDepth uint) *SearchResult {
+	return ai.Search(context.Background(), b, SearchLimits{
+		Clock: Clock{MoveTime: timeToThink},
+		Depth: maxDepth,
+	}, nil)
 }
 
-func (ai *AI) GetBestMoveScore(b *Board,
-	timeToThink time.Duration, maxDepth uint) (*Move, float64) {
-	tree := ai.buildTree(b, timeToThink, maxDepth, false)
-	tree.factorize()
+func (ai *AI) GetBestMove(b *chess.Board, timeToThink time.Duration,
+	maxDepth uint) *chess.Move {
+	return ai.GetBestMoveScore(b, timeToThink, maxDepth).Move
+}
 
-	return tree.best, tree.score
+// claimsDraw returns true if the AI claims a draw that can be claimed, given
+// the result of its search for the side to move: it does unless it expects to
+// win.
+func claimsDraw(res *SearchResult, turn chess.Color) bool {
+	return res.Score*colorScore(turn) <= 0
 }
 
-func (ai *AI) GetBestMove(b *Board, timeToThink time.Duration,
-	maxDepth uint) *Move {
-	move, _ := ai.GetBestMoveScore(b, timeToThink, maxDepth)
-	return move
+// colorScore returns 1 for white and -1 for black, scores being relative to
+// white.
+func colorScore(c chess.Color) float64 {
+	if c == chess.White {
+		return 1.0
+	}
+
+	return -1.0
 }
 
-func (ai *AI) evalPieces(b *Board, info *boardInfo) float64 {
+func (ai *AI) evalPieces(b *chess.Board, info *boardInfo) float64 {
 	score := 0.0
 
-	for _, piece := range b.squares {
-		if piece == nil || piece.kind == King {
+	for pos := chess.Position(0); pos < 64; pos++ {
+		piece, ok := b.PieceAt(pos)
+		if !ok || piece.Kind() == chess.King {
 			continue
 		}
 
-		val := ai.getGene("PieceValue" + piece.kind.GetName())
-		score += val * piece.color.score()
+		val := ai.getGene("PieceValue" + piece.Kind().GetName())
+		score += val * colorScore(piece.Color())
 
 		info.nbPieces++
 
 		var counter *boardInfoPiecesCount
 
-		if piece.color == White {
+		if piece.Color() == chess.White {
 			counter = &info.whiteCount
 		} else {
 			counter = &info.blackCount
 		}
 
-		switch piece.kind {
-		case Pawn:
+		switch piece.Kind() {
+		case chess.Pawn:
 			counter.pawn++
-		case Knight:
+		case chess.Knight:
 			counter.knight++
-		case Bishop:
+		case chess.Bishop:
 			counter.bishop++
-		case Rook:
+		case chess.Rook:
 			counter.rook++
-		case Queen:
+		case chess.Queen:
 			counter.queen++
 		}
 	}
@@ -361,23 +334,12 @@ func (ai *AI) evalPieces(b *Board, info *boardInfo) float64 {
 	return score
 }
 
-func (ai *AI) evalPositionnbMoves(b *Board, info *boardInfo) float64 {
+func (ai *AI) evalPositionnbMoves(b *chess.Board, info *boardInfo) float64 {
 	var whiteMoves int
 	var blackMoves int
 
-	if b.turn == White {
-		whiteMoves = len(b.GetMoves())
-
-		b.turn.swap()
-		blackMoves = len(b.getMovesOpts(true, true, false))
-		b.turn.swap()
-	} else {
-		blackMoves = len(b.GetMoves())
-
-		b.turn.swap()
-		whiteMoves = len(b.getMovesOpts(true, true, false))
-		b.turn.swap()
-	}
+	whiteMoves = len(b.GetMovesOf(chess.White))
+	blackMoves = len(b.GetMovesOf(chess.Black))
 
 	// Ignore winning-side number of moves.
 	if info.whiteCount.total == 0 {
@@ -389,8 +351,8 @@ func (ai *AI) evalPositionnbMoves(b *Board, info *boardInfo) float64 {
 	return float64(whiteMoves-blackMoves) * ai.getGene("NbMovesFactor")
 }
 
-func (ai *AI) evalPiecesPosition(b *Board,
-	ignoreColor *Color, beginnng bool) float64 {
+func (ai *AI) evalPiecesPosition(b *chess.Board,
+	ignoreColor *chess.Color, beginnng bool) float64 {
 	var tables *TablesStage
 	var score float64
 
@@ -407,14 +369,15 @@ func (ai *AI) evalPiecesPosition(b *Board,
 	queenFactor := ai.getGene("PiecePositionQueen")
 	kingFactor := ai.getGene("PiecePositionKing")
 
-	for pos, piece := range b.squares {
-		if piece == nil {
+	for pos := chess.Position(0); pos < 64; pos++ {
+		piece, ok := b.PieceAt(pos)
+		if !ok {
 			continue
 		}
 
 		if ignoreColor != nil &&
-			*ignoreColor == piece.color &&
-			piece.kind != Pawn {
+			*ignoreColor == piece.Color() &&
+			piece.Kind() != chess.Pawn {
 			// Make sure to never ignore pawns
 			continue
 		}
@@ -422,28 +385,28 @@ func (ai *AI) evalPiecesPosition(b *Board,
 		var tbc *TablesColor
 		var res float64
 
-		if piece.color == White {
+		if piece.Color() == chess.White {
 			tbc = tables.White
 		} else {
 			tbc = tables.Black
 		}
 
-		switch piece.kind {
-		case Pawn:
+		switch piece.Kind() {
+		case chess.Pawn:
 			res = tbc.Pawn[pos] * pawnFactor
-		case Knight:
+		case chess.Knight:
 			res = tbc.Knight[pos] * knightFactor
-		case Bishop:
+		case chess.Bishop:
 			res = tbc.Bishop[pos] * bishopFactor
-		case Rook:
+		case chess.Rook:
 			res = tbc.Rook[pos] * rookFactor
-		case Queen:
+		case chess.Queen:
 			res = tbc.Queen[pos] * queenFactor
-		case King:
+		case chess.King:
 			res = tbc.King[pos] * kingFactor
 		}
 
-		if piece.color == White {
+		if piece.Color() == chess.White {
 			score += res
 		} else {
 			score -= res
@@ -453,23 +416,48 @@ func (ai *AI) evalPiecesPosition(b *Board,
 	return score
 }
 
-func (ai *AI) evalPosition(b *Board) float64 {
+// evalHangingPieces returns the material the side to move can win by
+// capturing a piece of the other side, according to the static exchange
+// evaluation.
+func (ai *AI) evalHangingPieces(b *chess.Board) float64 {
+	best := 0
+
+	for pos := chess.Position(0); pos < 64; pos++ {
+		piece, ok := b.PieceAt(pos)
+		if !ok || piece.Kind() == chess.King || piece.Color() == b.Turn() {
+			continue
+		}
+
+		for _, from := range b.AttackersTo(pos, b.Turn()) {
+			move := chess.NewMove(from, pos)
+			if see := b.SEE(&move); see > best {
+				best = see
+			}
+		}
+	}
+
+	// From centipawns to pawns.
+	return float64(best) / 100 * colorScore(b.Turn()) *
+		ai.getGene("HangingPieces")
+}
+
+func (ai *AI) evalPosition(b *chess.Board) float64 {
 	var info boardInfo
 	var score float64
 
 	score += ai.evalPieces(b, &info)
 
-	if b.nbMoves > 30 {
+	if b.FullMoveNumber() > 15 {
 		score += ai.evalPositionnbMoves(b, &info)
 	}
 
 	if info.whiteCount.total == 0 {
 		// Ignore black piece position to get a faster checkmate
-		color := Black
+		color := chess.Black
 		score += ai.evalPiecesPosition(b, &color, false)
 	} else if info.blackCount.total == 0 {
 		// Ignore white piece position to get a faster checkmate
-		color := White
+		color := chess.White
 		score += ai.evalPiecesPosition(b, &color, false)
 	} else {
 		endgame := uint(math.Floor(ai.getGene("EndgameNbPieces") + 0.5))
@@ -477,6 +465,10 @@ func (ai *AI) evalPosition(b *Board) float64 {
 			info.whiteCount.total+info.blackCount.total > endgame)
 	}
 
+	if ai.getGene("HangingPieces") > 0 {
+		score += ai.evalHangingPieces(b)
+	}
+
 	return score
 }
 
@@ -499,48 +491,60 @@ func (ai *AI) getResult(p1 *AI, p2 *AI, score float64) [2]*Result {
 	return res
 }
 
-func (ai *AI) Play(opponent *AI, color Color,
-	timeToThink time.Duration, maxDepth uint, results chan [2]*Result) {
-	b := NewBoard()
-	factor := color.score()
+func (ai *AI) Play(ctx context.Context, opponent *AI, start *chess.Board,
+	color chess.Color, limits SearchLimits, results chan [2]*Result) {
+	b := start.Clone()
+	state := ai.playGame(ctx, opponent, b, color, limits)
 
-	for {
-		var state State
-		var move *Move
+	results <- ai.gameResult(opponent, state, color)
+}
 
-		if b.nbMoves > 200 {
-			// No need to waste more time.
-			results <- ai.getResult(ai, opponent, 0.0)
-			break
+// playGame plays a game on the board until it ends, and returns how it ended.
+// The moves of the game can be found in the board. If the context is done
+// before the end of the game, it is left unfinished (StatePlaying).
+func (ai *AI) playGame(ctx context.Context, opponent *AI, b *chess.Board,
+	color chess.Color, limits SearchLimits) chess.State {
+	for {
+		player := ai
+		if b.Turn() != color {
+			player = opponent
 		}
 
-		if b.turn == color {
-			move = ai.GetBestMove(b, timeToThink, maxDepth)
-		} else {
-			move = opponent.GetBestMove(b, timeToThink, maxDepth)
+		res := player.Search(ctx, b, limits, nil)
+		if ctx.Err() != nil {
+			return chess.StatePlaying
 		}
 
-		state = b.Move(move)
-		if state == StatePlaying {
-			continue
+		// Claimable draws are always claimed in tournaments.
+		state := b.Move(res.Move)
+		if state != chess.StatePlaying {
+			return state
 		}
+	}
+}
 
-		switch state {
-		case StateDrawByRepetition:
-			fallthrough
-		case StateDrawByStalemate:
-			fallthrough
-		case StateDrawByInsufficientMaterial:
-			results <- ai.getResult(ai, opponent, 0.0)
-		case StateWhiteWins:
-			results <- ai.getResult(ai, opponent, 1.0*factor)
-		case StateBlackWins:
-			results <- ai.getResult(ai, opponent, -1.0*factor)
-		default:
-			panic(fmt.Sprintf("unknown state %v", state))
-		}
+// gameResult returns the scores of a finished game, the ai playing the given
+// color.
+func (ai *AI) gameResult(opponent *AI, state chess.State, color chess.Color) [2]*Result {
+	factor := colorScore(color)
 
-		break
+	switch state {
+	case chess.StateDrawByRepetition,
+		chess.StateDrawByStalemate,
+		chess.StateDrawByInsufficientMaterial,
+		chess.StateDrawByFiftyMoves,
+		chess.StateDrawBySeventyFiveMoves,
+		chess.StateDrawByFivefoldRepetition:
+		return ai.getResult(ai, opponent, 0.0)
+	case chess.StateWhiteWins:
+		return ai.getResult(ai, opponent, 1.0*factor)
+	case chess.StateBlackWins:
+		return ai.getResult(ai, opponent, -1.0*factor)
+	case chess.StatePlaying:
+		// Games left unfinished by an aborted tournament are draws.
+		return ai.getResult(ai, opponent, 0.0)
+	default:
+		panic(fmt.Sprintf("unknown state %v", state))
 	}
 }
 
@@ -552,7 +556,7 @@ func (ai *AI) getRandID(n uint) string {
 
 	id := make([]rune, n)
 	for i := uint(0); i < n; i++ {
-		id[i] = chars[rand.Intn(len(chars))]
+		id[i] = chars[rng.Intn(len(chars))]
 	}
 
 	return string(id)
@@ -571,15 +575,23 @@ func (ai *AI) mute(nbGenes uint, size float64) *AI {
 	selectedGenes := make(map[int]bool)
 
 	for uint(len(selectedGenes)) < nbGenes {
-		selectedGenes[rand.Int()%len(genes)] = true
+		selectedGenes[rng.Int()%len(genes)] = true
 	}
 
+	// The genes are mutated in a fixed order, for the mutations to only
+	// depend on the seed.
+	var selected []int
 	for i := range selectedGenes {
+		selected = append(selected, i)
+	}
+	sort.Ints(selected)
+
+	for _, i := range selected {
 		gene := genes[i]
 		val := ai.getGene(gene.name)
 
 		for {
-			diff := (0.5 - rand.Float64()) * (gene.max - gene.min)
+			diff := (0.5 - rng.Float64()) * (gene.max - gene.min)
 
 			newVal := val + diff
 			newVal = math.Min(newVal, gene.max)
@@ -1,17 +1,54 @@
 package geneticchess
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"math/rand"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
 )
 
+func newMove(from chess.Position, to chess.Position) *chess.Move {
+	move := chess.NewMove(from, to)
+	return &move
+}
+
+func newPromotion(from chess.Position, to chess.Position,
+	promoteTo chess.PieceType) *chess.Move {
+	move := chess.NewPromotion(from, to, promoteTo)
+	return &move
+}
+
+// newTestBoard returns the board of the diagram, with the given side to move
+// and number of half-moves already played.
+func newTestBoard(t *testing.T, diagram string, turn chess.Color,
+	nbMoves int) *chess.Board {
+	b, err := chess.NewBoardFromDiagram(diagram, turn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	fields := strings.Fields(b.FEN())
+	fields[5] = strconv.Itoa(nbMoves/2 + 1)
+
+	b, err = chess.NewBoardFromFEN(strings.Join(fields, " "))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return b
+}
+
 func TestAIEvalPieceValues(t *testing.T) {
 	var info boardInfo
 
 	ai := NewAI()
-	b := NewBoard()
+	b := chess.NewBoard()
 
 	score := ai.evalPieces(b, &info)
 	if score != 0.0 {
@@ -19,9 +56,9 @@ func TestAIEvalPieceValues(t *testing.T) {
 			0.0, score, fmt.Sprintf(""))
 	}
 
-	_ = b.Move(&Move{from: 52, to: 36})
-	_ = b.Move(&Move{from: 11, to: 27})
-	_ = b.Move(&Move{from: 36, to: 27})
+	_ = b.Move(newMove(52, 36))
+	_ = b.Move(newMove(11, 27))
+	_ = b.Move(newMove(36, 27))
 
 	score = ai.evalPieces(b, &info)
 	if score != 1.0 {
@@ -30,37 +67,37 @@ func TestAIEvalPieceValues(t *testing.T) {
 }
 
 func TestAIPieceValueBestMove(t *testing.T) {
-	b := NewBoardFromDiagram(
-		"    | | | | | | | | |" +
-			"| | | | | | | | |" +
-			"| | |N| |N| | | |" +
-			"| | | |b| | | | |" +
-			"| | |R| |P| | | |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |" +
-			"|k| | | | |K| | |")
+	b := newTestBoard(t,
+		"    | | | | | | | | |"+
+			"| | | | | | | | |"+
+			"| | |N| |N| | | |"+
+			"| | | |b| | | | |"+
+			"| | |R| |P| | | |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |"+
+			"|k| | | | |K| | |", chess.White, 0)
 	ai := NewAI()
-	move := ai.GetBestMove(b, 0, 1)
-	if move.from != 27 || move.to != 34 {
+	move := ai.GetBestMove(b, 0, 2)
+	if move.From() != 27 || move.To() != 34 {
 		t.Fatalf("expected move to be 27:34 instead of %d:%d",
-			move.from, move.to)
+			move.From(), move.To())
 	}
 }
 
 func TestAICheckmate(t *testing.T) {
 	ai := NewAI()
-	b := NewBoard()
-	_ = b.Move(&Move{from: 52, to: 36})
-	_ = b.Move(&Move{from: 12, to: 28})
-	_ = b.Move(&Move{from: 59, to: 45})
-	_ = b.Move(&Move{from: 8, to: 16})
-	_ = b.Move(&Move{from: 61, to: 34})
-	_ = b.Move(&Move{from: 16, to: 24})
-
-	move := ai.GetBestMove(b, 0, 1)
-	if move.from != 45 || move.to != 13 {
+	b := chess.NewBoard()
+	_ = b.Move(newMove(52, 36))
+	_ = b.Move(newMove(12, 28))
+	_ = b.Move(newMove(59, 45))
+	_ = b.Move(newMove(8, 16))
+	_ = b.Move(newMove(61, 34))
+	_ = b.Move(newMove(16, 24))
+
+	move := ai.GetBestMove(b, 0, 2)
+	if move.From() != 45 || move.To() != 13 {
 		t.Fatalf("expected move to be 45:13 instead of %d:%d",
-			move.from, move.to)
+			move.From(), move.To())
 	}
 }
 
@@ -72,36 +109,271 @@ func TestAIBugKing(t *testing.T) {
 	ai.Genes["PieceValueKnight"] = 0.1
 	ai.Genes["PieceValueBishop"] = 0.1
 	ai.Genes["PieceValueQueen"] = 0.1
-	b := NewBoardFromDiagram(
-		"    | | | | | |B|R| |" +
-			"| | | |r|P| |P| |" +
-			"| | | | | |P| |P|" +
-			"| | |q| |K| | | |" +
-			"| | |b| | | | | |" +
-			"| | | | | | | | |" +
-			"| |p|p|p| |p|p|p|" +
-			"| |n|b| | |k|n|r|")
-	b.turn = Black
-
-	move := ai.GetBestMove(b, 0, 1)
+	b := newTestBoard(t,
+		"    | | | | | |B|R| |"+
+			"| | | |r|P| |P| |"+
+			"| | | | | |P| |P|"+
+			"| | |q| |K| | | |"+
+			"| | |b| | | | | |"+
+			"| | | | | | | | |"+
+			"| |p|p|p| |p|p|p|"+
+			"| |n|b| | |k|n|r|", chess.Black, 0)
+
+	move := ai.GetBestMove(b, 0, 2)
 	_ = b.Move(move)
 }
 
-func TestAIBuildTree(t *testing.T) {
+func TestAISearchRoot(t *testing.T) {
 	ai := NewAI()
-	b := NewBoardFromDiagram(
-		"    | | | | | | | | |" +
-			"| | | | | | | | |" +
-			"|K| | | | | | | |" +
-			"|p| | | | | | | |" +
-			"|k| | | | | | | |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |")
+	b := newTestBoard(t,
+		"    | | | | | | | | |"+
+			"| | | | | | | | |"+
+			"|K| | | | | | | |"+
+			"|p| | | | | | | |"+
+			"|k| | | | | | | |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |", chess.White, 0)
+
+	s := ai.newSearcher(context.Background(), SearchLimits{})
+	move, _ := s.searchRoot(b, 3, nil)
+	if move == nil {
+		t.Fatalf("expected a move")
+	}
+	if s.nodes == 0 {
+		t.Fatalf("expected nodes to be searched")
+	}
+}
+
+func TestAISearchNoMoves(t *testing.T) {
+	ai := NewAI()
+	b, _ := chess.NewBoardFromFEN("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+
+	res := ai.GetBestMoveScore(b, 0, 2)
+	if res.Move != nil {
+		t.Fatalf("expected no move instead of %s", res.Move)
+	}
+	if res.Score != 0 || len(res.PV) != 0 {
+		t.Fatalf("expected score 0 and no pv instead of %f %v",
+			res.Score, res.PV)
+	}
+}
+
+func TestAIAlphaBeta(t *testing.T) {
+	// Alpha-beta must find the same score as a plain minimax.
+	ai := NewAI()
+	ai.Genes["PruneRatio"] = 0.0
+	ai.Genes["QuiescenceDepth"] = 0.0
+
+	var minimax func(b *chess.Board, depth int, ply int) float64
+	minimax = func(b *chess.Board, depth int, ply int) float64 {
+		if depth == 0 {
+			return ai.evalPosition(b) * colorScore(b.Turn())
+		}
 
-	tree := ai.buildTree(b, 0, 1, false)
-	if tree == nil {
-		panic("")
+		best := -mateScore * 2
+		for _, move := range b.GetMoves() {
+			var score float64
+
+			newBoard := b.Clone()
+			state := newBoard.Move(&move)
+			if state != chess.StatePlaying {
+				score = terminalScore(state, ply+1)
+			} else {
+				score = -minimax(newBoard, depth-1, ply+1)
+			}
+
+			if score > best {
+				best = score
+			}
+		}
+
+		return best
+	}
+
+	fens := []string{
+		"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+	}
+
+	for i, fen := range fens {
+		b, _ := chess.NewBoardFromFEN(fen)
+
+		s := ai.newSearcher(context.Background(), SearchLimits{})
+		_, score := s.searchRoot(b, 2, nil)
+		expected := minimax(b, 2, 0)
+
+		if math.Abs(score-expected) > 0.0001 {
+			t.Errorf("test %d: expected score %f instead of %f",
+				i, expected, score)
+		}
+	}
+}
+
+func TestAIQuiescence(t *testing.T) {
+	// The pawn on d5 is defended, taking it loses the queen.
+	b, _ := chess.NewBoardFromFEN("6k1/8/4p3/3p4/8/8/8/3Q2K1 w - - 0 1")
+	capture, _ := chess.ParseSAN(b, "Qxd5")
+
+	// Hanging pieces would also show that the queen is lost.
+	ai := NewAI()
+	ai.Genes["QuiescenceDepth"] = 0.0
+	ai.Genes["HangingPieces"] = 0.0
+
+	s := ai.newSearcher(context.Background(), SearchLimits{})
+	move, _ := s.searchRoot(b, 1, nil)
+	if !move.Equals(capture) {
+		t.Fatalf("expected Qxd5 without quiescence instead of %s",
+			move.SAN(b))
+	}
+
+	for _, delta := range []float64{0.0, 2.0} {
+		ai = NewAI()
+		ai.Genes["DeltaMargin"] = delta
+
+		s = ai.newSearcher(context.Background(), SearchLimits{})
+		move, score := s.searchRoot(b, 1, nil)
+		if move.Equals(capture) {
+			t.Fatalf("delta %f: Qxd5 should be avoided", delta)
+		}
+		if score < 5 {
+			t.Fatalf("delta %f: expected a winning score instead of %f",
+				delta, score)
+		}
+	}
+}
+
+func TestAIEvalHangingPieces(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected float64
+	}{
+		// The knight on e5 is defended.
+		{"4k3/8/3p4/4n3/8/8/8/4RK2 w - - 0 1", 0},
+		// The knight on e5 can be taken by the rook.
+		{"4k3/8/8/4n3/8/8/8/4RK2 w - - 0 1", 3},
+		// Only the best capture is counted.
+		{"4k3/8/8/4n3/8/2b5/8/Q3RK2 w - - 0 1", 3},
+		// The rook is defended by the king, relative to white.
+		{"4k3/8/8/8/8/5n2/8/4RK2 b - - 0 1", -2},
+	}
+
+	ai := NewAI()
+	ai.Genes["HangingPieces"] = 1.0
+
+	for i, test := range tests {
+		b, err := chess.NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		score := ai.evalHangingPieces(b)
+		if math.Abs(score-test.expected) > 0.0001 {
+			t.Errorf("test %d: expected %f instead of %f",
+				i, test.expected, score)
+		}
+	}
+}
+
+func TestAINullMove(t *testing.T) {
+	ai := NewAI()
+	s := ai.newSearcher(context.Background(), SearchLimits{})
+
+	// White is a queen up, passing is still good enough.
+	b, _ := chess.NewBoardFromFEN("4k3/8/8/8/8/8/8/3QK3 w - - 0 1")
+	if _, ok := s.nullMove(b, 4, 1, 0); !ok {
+		t.Fatalf("expected a null move cutoff")
+	}
+
+	// Pawn ending, the side to move may be in zugzwang.
+	b, _ = chess.NewBoardFromFEN("8/8/8/3k4/8/3K4/3P4/8 w - - 0 1")
+	if _, ok := s.nullMove(b, 4, 1, -10); ok {
+		t.Fatalf("null move not allowed in pawn endings")
+	}
+
+	ai.Genes["NullMoveReduction"] = 0.0
+	s = ai.newSearcher(context.Background(), SearchLimits{})
+	b, _ = chess.NewBoardFromFEN("4k3/8/8/8/8/8/8/3QK3 w - - 0 1")
+	if _, ok := s.nullMove(b, 4, 1, 0); ok {
+		t.Fatalf("null move disabled by a reduction of 0")
+	}
+}
+
+func TestAILateMoveReduction(t *testing.T) {
+	ai := NewAI()
+	s := ai.newSearcher(context.Background(), SearchLimits{})
+
+	b, _ := chess.NewBoardFromFEN(
+		"r1bqkbnr/pppp1ppp/2n5/4p3/3PP3/5N2/PPP2PPP/RNBQKB1R b KQkq - 0 3")
+	quiet := newMove(14, 22)
+	capture := newMove(28, 35)
+
+	tests := []struct {
+		move     *chess.Move
+		i        int
+		depth    int
+		inCheck  bool
+		expected int
+	}{
+		{quiet, 10, 5, false, 1},
+		// The first moves are searched at full depth.
+		{quiet, 3, 5, false, 0},
+		{quiet, 10, 2, false, 0},
+		{quiet, 10, 5, true, 0},
+		{capture, 10, 5, false, 0},
+	}
+
+	for i, test := range tests {
+		r := s.lateMoveReduction(b, test.move, test.i, test.depth,
+			test.inCheck)
+		if r != test.expected {
+			t.Errorf("test %d: expected %d instead of %d",
+				i, test.expected, r)
+		}
+	}
+}
+
+func TestAISelectivity(t *testing.T) {
+	fens := []string{
+		"r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+	}
+
+	for i, fen := range fens {
+		b, _ := chess.NewBoardFromFEN(fen)
+
+		ai := NewAI()
+		s := ai.newSearcher(context.Background(), SearchLimits{})
+		s.iterativeDeepening(b, 4)
+		selective := s.nodes
+
+		ai = NewAI()
+		ai.Genes["NullMoveReduction"] = 0.0
+		ai.Genes["LMRReduction"] = 0.0
+		s = ai.newSearcher(context.Background(), SearchLimits{})
+		s.iterativeDeepening(b, 4)
+
+		if selective >= s.nodes {
+			t.Errorf("test %d: expected less than %d nodes instead of %d",
+				i, s.nodes, selective)
+		}
+	}
+}
+
+func TestAIFromJSONMissingGenes(t *testing.T) {
+	ai, err := NewAIFromJSON([]byte(`{"Genes": {"PieceValuePawn": 2}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ai.getGene("PieceValuePawn") != 2 {
+		t.Fatalf("existing gene should be kept")
+	}
+	if ai.getGene("QuiescenceDepth") != NewAI().getGene("QuiescenceDepth") {
+		t.Fatalf("missing gene should have its default value")
+	}
+	if ai.getGene("HangingPieces") != 0 {
+		t.Fatalf("missing evaluation gene should be disabled")
 	}
 }
 
@@ -111,8 +383,8 @@ func TestAIGetBestMoveForcedCheckmate(t *testing.T) {
 
 	tests := []struct {
 		diagram string
-		move    *Move
-		turn    Color
+		move    *chess.Move
+		turn    chess.Color
 	}{
 		{"   | | | | | | | | |" +
 			"| | | | | | | | |" +
@@ -122,7 +394,7 @@ func TestAIGetBestMoveForcedCheckmate(t *testing.T) {
 			"| |k| | | | | | |" +
 			"| | | |n| | | | |" +
 			"|K| | | |n| | | |",
-			&Move{from: 60, to: 50}, White},
+			newMove(60, 50), chess.White},
 		{"   | | | | | | | | |" +
 			"| | | | | | | | |" +
 			"| | | | | | | | |" +
@@ -131,7 +403,7 @@ func TestAIGetBestMoveForcedCheckmate(t *testing.T) {
 			"| | |k| | | | | |" +
 			"| | | | | | | | |" +
 			"|K| | | | | | | |",
-			&Move{from: 42, to: 50}, White},
+			newMove(42, 50), chess.White},
 		{"   |k| | | |r| | | |" +
 			"| | | | |n|r| | |" +
 			"| | | | | | | | |" +
@@ -140,7 +412,7 @@ func TestAIGetBestMoveForcedCheckmate(t *testing.T) {
 			"| | | | | | | | |" +
 			"| | | | | | | | |" +
 			"| | | | | | |K| |",
-			&Move{from: 4, to: 6}, White},
+			newMove(4, 6), chess.White},
 		{"   | | | | | | | | |" +
 			"| | | | | |p| |K|" +
 			"| | | | | | |P|P|" +
@@ -149,7 +421,7 @@ func TestAIGetBestMoveForcedCheckmate(t *testing.T) {
 			"| |b|b| | | | | |" +
 			"| | | | | | | | |" +
 			"|k| | | | | | | |",
-			&Move{from: 13, to: 5, promoteTo: Knight}, White},
+			newPromotion(13, 5, chess.Knight), chess.White},
 		{"   |R| | | | | |R|K|" +
 			"| |P| | | | |P|P|" +
 			"| | |P|n| | | | |" +
@@ -158,7 +430,7 @@ func TestAIGetBestMoveForcedCheckmate(t *testing.T) {
 			"| | | |p| | |p| |" +
 			"|p|p|p| | | |b|p|" +
 			"| |k|r| | | | |r|",
-			&Move{from: 19, to: 13}, White},
+			newMove(19, 13), chess.White},
 		{"   | |R| | | | | | |" +
 			"| |N| | | | | | |" +
 			"| | | | | | | | |" +
@@ -167,14 +439,12 @@ func TestAIGetBestMoveForcedCheckmate(t *testing.T) {
 			"| | | | | |K| | |" +
 			"| | | | | | | | |" +
 			"| | | | | | | |k|",
-			&Move{from: 45, to: 53}, Black},
+			newMove(45, 53), chess.Black},
 	}
 
 	for i, test := range tests {
-		b := NewBoardFromDiagram(test.diagram)
-		b.turn = test.turn
-		b.nbMoves = 100
-		move := ai.GetBestMove(b, 0, 2)
+		b := newTestBoard(t, test.diagram, test.turn, 100)
+		move := ai.GetBestMove(b, 0, 3)
 
 		if !move.Equals(test.move) {
 			t.Errorf("test %d: expected move %s instead of %s",
@@ -188,47 +458,51 @@ func TestAIPruneNodes(t *testing.T) {
 
 	tests := []struct {
 		list         []PrunableNode
-		turn         Color
 		pruneRate    float64
 		minKeptNodes uint
 		scores       []float64
 	}{
 		{[]PrunableNode{
-			PrunableNode{node: &Node{score: 1.5}},
-			PrunableNode{node: &Node{score: 2.1}},
-			PrunableNode{node: &Node{score: 1.7}},
-			PrunableNode{node: &Node{score: 2.0}},
-		}, Black, 0.5, 1, []float64{1.5, 1.7}},
+			PrunableNode{score: 1.5},
+			PrunableNode{score: 2.1},
+			PrunableNode{score: 1.7},
+			PrunableNode{score: 2.0},
+		}, 0.5, 1, []float64{2.1, 2.0}},
 		{[]PrunableNode{
-			PrunableNode{node: &Node{score: 1.5}},
-			PrunableNode{node: &Node{score: 2.1}},
-			PrunableNode{node: &Node{score: 1.7}},
-			PrunableNode{node: &Node{score: 2.0}},
-		}, Black, 0.5, 3, []float64{1.5, 1.7, 2.0}},
+			PrunableNode{score: 1.5},
+			PrunableNode{score: 2.1},
+			PrunableNode{score: 1.7},
+			PrunableNode{score: 2.0},
+		}, 0.5, 3, []float64{2.1, 2.0, 1.7}},
 		{[]PrunableNode{
-			PrunableNode{node: &Node{score: 1.5}},
-		}, Black, 0.5, 42, []float64{1.5}},
+			PrunableNode{score: 1.5},
+		}, 0.5, 42, []float64{1.5}},
 		{[]PrunableNode{
-			PrunableNode{node: &Node{score: 1.5}},
-			PrunableNode{node: &Node{score: 2.1}},
-			PrunableNode{node: &Node{score: 1.7}},
-			PrunableNode{node: &Node{score: 2.0}},
-		}, White, 0.5, 2, []float64{2.0, 2.1}},
+			PrunableNode{score: 1.5},
+			PrunableNode{score: 2.1},
+			PrunableNode{score: 1.7},
+			PrunableNode{score: 2.0},
+		}, 0.0, 1, []float64{2.1, 2.0, 1.7, 1.5}},
+		{[]PrunableNode{
+			PrunableNode{score: -1.5},
+			PrunableNode{score: 2.1},
+			PrunableNode{score: 1.7},
+			PrunableNode{score: -2.0},
+		}, 0.99, 0, []float64{2.1}},
 	}
 
 	for i, test := range tests {
-		list := ai.pruneNodes(test.list,
-			test.turn, test.pruneRate, test.minKeptNodes)
+		list := ai.pruneNodes(test.list, test.pruneRate, test.minKeptNodes)
 		if len(list) != len(test.scores) {
 			t.Fatalf("test %d: expected len of %d instead of %d",
 				i, len(test.scores), len(list))
 		}
 
 		for j := range list {
-			if test.scores[j] != list[j].node.score {
+			if test.scores[j] != list[j].score {
 				t.Fatalf("test %d: expected elem %d "+
 					"to be %.2f instead of %.2f",
-					i, j, test.scores[j], list[j].node.score)
+					i, j, test.scores[j], list[j].score)
 			}
 		}
 	}
@@ -236,13 +510,13 @@ func TestAIPruneNodes(t *testing.T) {
 
 func TestAIEvalCompare(t *testing.T) {
 	tests := []struct {
-		turn    Color
+		turn    chess.Color
 		better  string
 		nbMoves int
 		worse   string
 	}{
 		{
-			White,
+			chess.White,
 			"    | | | | | | | | |" +
 				"| | | | | | |K| |" +
 				"| | | | | | |P| |" +
@@ -262,7 +536,7 @@ func TestAIEvalCompare(t *testing.T) {
 				"|k| | | | | | | |",
 		},
 		{
-			White,
+			chess.White,
 			"    | | | | | | |r| |" +
 				"| | | | | |r| | |" +
 				"| | | | | | | | |" +
@@ -282,7 +556,7 @@ func TestAIEvalCompare(t *testing.T) {
 				"|k| | | | | |K| |",
 		},
 		{
-			White,
+			chess.White,
 			"    |R|N|B|Q|K|B|N|R|" +
 				"|P|P|P|P| |P|P|P|" +
 				"| | | | | | | | |" +
@@ -302,7 +576,7 @@ func TestAIEvalCompare(t *testing.T) {
 				"|r|n|b|q|k| |n|r|",
 		},
 		{
-			White,
+			chess.White,
 			"    |R| |B|Q|K|B|N|R|" +
 				"|P|P|P|P|P|P|P|P|" +
 				"|N| | | | | | | |" +
@@ -322,7 +596,7 @@ func TestAIEvalCompare(t *testing.T) {
 				"|r|n|b|q|k|b|n|r|",
 		},
 		{
-			Black,
+			chess.Black,
 			"    |R|N|B|Q|K| |N|R|" +
 				"|P|P|P| | |P|P|P|" +
 				"| | | | | | | | |" +
@@ -346,12 +620,8 @@ func TestAIEvalCompare(t *testing.T) {
 	ai := NewAI()
 
 	for i, test := range tests {
-		better := NewBoardFromDiagram(test.better)
-		worse := NewBoardFromDiagram(test.worse)
-		better.turn = test.turn
-		worse.turn = test.turn
-		better.nbMoves = test.nbMoves
-		worse.nbMoves = test.nbMoves
+		better := newTestBoard(t, test.better, test.turn, test.nbMoves)
+		worse := newTestBoard(t, test.worse, test.turn, test.nbMoves)
 		betterScore := ai.evalPosition(better)
 		worseScore := ai.evalPosition(worse)
 
@@ -365,27 +635,27 @@ func TestAIEvalCompare(t *testing.T) {
 
 func TestAIEvalEndgamePawn(t *testing.T) {
 	ai := NewAI()
-	b := NewBoardFromDiagram(
-		"    | | | | | | | | |" +
-			"| | | | | | |K| |" +
-			"| | | | | | |P| |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |" +
-			"|k| | | | | | | |")
+	b := newTestBoard(t,
+		"    | | | | | | | | |"+
+			"| | | | | | |K| |"+
+			"| | | | | | |P| |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |"+
+			"|k| | | | | | | |", chess.White, 0)
 
 	score := ai.evalPosition(b)
 
-	b = NewBoardFromDiagram(
-		"    | | | | | | | | |" +
-			"| | | | | | |K| |" +
-			"| | | | | | | | |" +
-			"| | | | | | |P| |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |" +
-			"| | | | | | | | |" +
-			"|k| | | | | | | |")
+	b = newTestBoard(t,
+		"    | | | | | | | | |"+
+			"| | | | | | |K| |"+
+			"| | | | | | | | |"+
+			"| | | | | | |P| |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |"+
+			"| | | | | | | | |"+
+			"|k| | | | | | | |", chess.White, 0)
 
 	score2 := ai.evalPosition(b)
 
@@ -396,7 +666,7 @@ func TestAIEvalEndgamePawn(t *testing.T) {
 
 func TestAIEvalPosition(t *testing.T) {
 	ai := NewAI()
-	b := NewBoard()
+	b := chess.NewBoard()
 
 	score := ai.evalPosition(b)
 	if score > 0.0001 || score < -0.0001 {
@@ -413,8 +683,8 @@ func TestAIBadMoves(t *testing.T) {
 
 	tests := []struct {
 		diagram string
-		turn    Color
-		badMove Move
+		turn    chess.Color
+		badMove chess.Move
 	}{
 		{"   |R| |B|Q|K|B|N|R|" +
 			"|P|P|P| | |P|P|P|" +
@@ -424,8 +694,8 @@ func TestAIBadMoves(t *testing.T) {
 			"| | | | | |n| | |" +
 			"|p|p|p|p| |p|p|p|" +
 			"|r| |b|q|k|b| |r|",
-			Black,
-			Move{from: 3, to: 27}},
+			chess.Black,
+			chess.NewMove(3, 27)},
 		{"   |R|N|B|Q|K|B|N|R|" +
 			"|P|P|P|P| |P|P|P|" +
 			"| | | | | | | | |" +
@@ -434,8 +704,8 @@ func TestAIBadMoves(t *testing.T) {
 			"| | |n| | | | | |" +
 			"|p|p|p|p| |p|p|p|" +
 			"|r| |b|q|k|b|n|r|",
-			Black,
-			Move{from: 11, to: 27}},
+			chess.Black,
+			chess.NewMove(11, 27)},
 		{"   |R|N| |Q| |R|K| |" +
 			"|P|P|P| | |P|P|P|" +
 			"| | | |B| | | | |" +
@@ -444,8 +714,8 @@ func TestAIBadMoves(t *testing.T) {
 			"| | |n|p| | | | |" +
 			"|p|p|p| |n|p|p|p|" +
 			"|r| |b|q| |r|k| |",
-			Black,
-			Move{from: 21, to: 27}},
+			chess.Black,
+			chess.NewMove(21, 27)},
 		{"   |R| | | | |R| |K|" +
 			"| |P|P| | |P|P| |" +
 			"|P| | | | | | |P|" +
@@ -454,8 +724,8 @@ func TestAIBadMoves(t *testing.T) {
 			"| |q| |p| | | | |" +
 			"|p|p| | | |p|p| |" +
 			"|r| | | |r| |k| |",
-			Black,
-			Move{from: 35, to: 34}},
+			chess.Black,
+			chess.NewMove(35, 34)},
 		{"   |R| | |Q| |R|K| |" +
 			"| |P|P| | |P|P| |" +
 			"|P| | |B| | | |P|" +
@@ -464,8 +734,8 @@ func TestAIBadMoves(t *testing.T) {
 			"|p|p| |p| | |b| |" +
 			"| |p| | | |p|p|p|" +
 			"|r| | | | |r|k| |",
-			Black,
-			Move{from: 19, to: 40}},
+			chess.Black,
+			chess.NewMove(19, 40)},
 		{"   |R| | | | |R|K| |" +
 			"| |P|P| | |P|P| |" +
 			"|P| | | | | | |P|" +
@@ -474,8 +744,8 @@ func TestAIBadMoves(t *testing.T) {
 			"|p|p| | | | | | |" +
 			"| | | | | |p|p|p|" +
 			"|r| | | |r| |k| |",
-			Black,
-			Move{from: 36, to: 60}},
+			chess.Black,
+			chess.NewMove(36, 60)},
 		{"   |R| | |Q| |R|K| |" +
 			"|P|P|P| | |P|P|P|" +
 			"| | |N| | | | | |" +
@@ -484,18 +754,17 @@ func TestAIBadMoves(t *testing.T) {
 			"|p| |p|p| |q| |p|" +
 			"| | |p|b| |p|p| |" +
 			"|r| | | |k| | |r|",
-			Black,
-			Move{from: 27, to: 42}},
+			chess.Black,
+			chess.NewMove(27, 42)},
 	}
 
 	for i, test := range tests {
 		if i != 0 {
 			continue
 		}
-		b := NewBoardFromDiagram(test.diagram)
-		b.turn = test.turn
+		b := newTestBoard(t, test.diagram, test.turn, 0)
 
-		move := ai.GetBestMove(b, 0, 2)
+		move := ai.GetBestMove(b, 0, 3)
 		if move.Equals(&test.badMove) {
 			t.Errorf("test %d: ai played a bad move %v", i, move)
 		}
@@ -505,17 +774,15 @@ func TestAIBadMoves(t *testing.T) {
 func TestAIGetBestMoveFromPos(t *testing.T) {
 	ai := NewAI()
 	ai.Genes["PruneRatio"] = 0.0
-	b := NewBoardFromDiagram(
-		"    |R|N|B|Q|K| |N|R|" +
-			"|P|P|P|P| |P|P|P|" +
-			"| | | | | | | | |" +
-			"| | | | |P| | | |" +
-			"| |B| | |p| | | |" +
-			"|p| |n| | | | | |" +
-			"| |p|p|p| |p|p|p|" +
-			"|r| |b|q|k|b|n|r|")
-
-	b.turn = Black
+	b := newTestBoard(t,
+		"    |R|N|B|Q|K| |N|R|"+
+			"|P|P|P|P| |P|P|P|"+
+			"| | | | | | | | |"+
+			"| | | | |P| | | |"+
+			"| |B| | |p| | | |"+
+			"|p| |n| | | | | |"+
+			"| |p|p|p| |p|p|p|"+
+			"|r| |b|q|k|b|n|r|", chess.Black, 0)
 	ai.evalPosition(b)
 }
 
@@ -524,3 +791,45 @@ func TestAIMutation(t *testing.T) {
 	ai := NewAIEmpty()
 	ai.mute(3, 1.0)
 }
+
+func TestAIWithOwnHash(t *testing.T) {
+	ai := NewAI()
+	ai.SetHashSize(1)
+
+	own := ai.withOwnHash()
+	if own.String() != ai.String() {
+		t.Fatalf("expected %s instead of %s", ai, own)
+	}
+
+	own.GetBestMove(chess.NewBoard(), 0, 3)
+	if own.tt == nil || own.tt == ai.tt {
+		t.Fatalf("expected the own to have its own table")
+	}
+
+	ai.mergeHash(own)
+	if ai.HashHitRate() != own.HashHitRate() || ai.HashHitRate() == 0 {
+		t.Fatalf("expected a hit rate of %f instead of %f",
+			own.HashHitRate(), ai.HashHitRate())
+	}
+}
+
+func TestAIClaimsDraw(t *testing.T) {
+	tests := []struct {
+		score  float64
+		turn   chess.Color
+		claims bool
+	}{
+		{0, chess.White, true},
+		{-1.5, chess.White, true},
+		{1.5, chess.White, false},
+		{1.5, chess.Black, true},
+		{-1.5, chess.Black, false},
+	}
+
+	for i, test := range tests {
+		res := &SearchResult{Score: test.score}
+		if claimsDraw(res, test.turn) != test.claims {
+			t.Errorf("test %d: expected claim %v", i, test.claims)
+		}
+	}
+}
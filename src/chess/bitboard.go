@@ -0,0 +1,251 @@
+package chess
+
+import (
+	"math/bits"
+)
+
+// A bitboard has one bit per square, bit i standing for Position i.
+type bitboard uint64
+
+var (
+	knightAttacks [64]bitboard
+	kingAttacks   [64]bitboard
+
+	// Squares attacked by a pawn, by color.
+	pawnAttacks [2][64]bitboard
+
+	// Squares from a square to the border of the board, by direction.
+	rays [8][64]bitboard
+
+	// Squares strictly between two aligned squares, empty if they are not
+	// aligned.
+	between [64][64]bitboard
+
+	// Whole line going through two aligned squares, empty if they are not
+	// aligned.
+	lines [64][64]bitboard
+)
+
+// Row and column offsets of the rays. The first four directions go toward
+// increasing positions, the last four ones toward decreasing positions.
+var rayDirections = [8][2]int{
+	{0, 1}, {1, -1}, {1, 0}, {1, 1},
+	{0, -1}, {-1, 1}, {-1, 0}, {-1, -1},
+}
+
+var (
+	rookDirections   = []int{0, 2, 4, 6}
+	bishopDirections = []int{1, 3, 5, 7}
+)
+
+func init() {
+	knightOffsets := [][2]int{
+		{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2},
+		{1, -2}, {1, 2}, {2, -1}, {2, 1},
+	}
+	kingOffsets := [][2]int{
+		{-1, -1}, {-1, 0}, {-1, 1}, {0, -1},
+		{0, 1}, {1, -1}, {1, 0}, {1, 1},
+	}
+
+	offsets := func(pos int, list [][2]int) bitboard {
+		var bb bitboard
+
+		for _, o := range list {
+			row, col := pos/8+o[0], pos%8+o[1]
+			if row >= 0 && row < 8 && col >= 0 && col < 8 {
+				bb |= squareBB(Position(row*8 + col))
+			}
+		}
+
+		return bb
+	}
+
+	for pos := 0; pos < 64; pos++ {
+		knightAttacks[pos] = offsets(pos, knightOffsets)
+		kingAttacks[pos] = offsets(pos, kingOffsets)
+
+		// White pawns go toward the top of the board.
+		pawnAttacks[0][pos] = offsets(pos, [][2]int{{-1, -1}, {-1, 1}})
+		pawnAttacks[1][pos] = offsets(pos, [][2]int{{1, -1}, {1, 1}})
+
+		for d, dir := range rayDirections {
+			row, col := pos/8+dir[0], pos%8+dir[1]
+
+			for row >= 0 && row < 8 && col >= 0 && col < 8 {
+				rays[d][pos] |= squareBB(Position(row*8 + col))
+				row, col = row+dir[0], col+dir[1]
+			}
+		}
+	}
+
+	for pos := 0; pos < 64; pos++ {
+		for d := range rayDirections {
+			for ray := rays[d][pos]; ray != 0; {
+				to := ray.pop()
+
+				between[pos][to] = rays[d][pos] &^ rays[d][to] &^ squareBB(to)
+				lines[pos][to] = rays[d][pos] | rays[(d+4)%8][pos] |
+					squareBB(Position(pos))
+			}
+		}
+	}
+}
+
+func squareBB(pos Position) bitboard {
+	return bitboard(1) << pos
+}
+
+func (bb bitboard) has(pos Position) bool {
+	return bb&squareBB(pos) != 0
+}
+
+func (bb bitboard) count() int {
+	return bits.OnesCount64(uint64(bb))
+}
+
+// first returns the lowest square of a non-empty bitboard.
+func (bb bitboard) first() Position {
+	return Position(bits.TrailingZeros64(uint64(bb)))
+}
+
+// pop removes the lowest square of a non-empty bitboard and returns it.
+func (bb *bitboard) pop() Position {
+	pos := bb.first()
+	*bb &= *bb - 1
+
+	return pos
+}
+
+// slidingAttacks returns the squares reached from pos in the given directions,
+// each ray stopping on the first occupied square.
+func slidingAttacks(pos Position, occupied bitboard, directions []int) bitboard {
+	var attacks bitboard
+
+	for _, d := range directions {
+		ray := rays[d][pos]
+		attacks |= ray
+
+		blockers := ray & occupied
+		if blockers == 0 {
+			continue
+		}
+
+		var blocker int
+		if d < 4 {
+			blocker = bits.TrailingZeros64(uint64(blockers))
+		} else {
+			blocker = 63 - bits.LeadingZeros64(uint64(blockers))
+		}
+
+		attacks &^= rays[d][blocker]
+	}
+
+	return attacks
+}
+
+func rookAttacks(pos Position, occupied bitboard) bitboard {
+	return slidingAttacks(pos, occupied, rookDirections)
+}
+
+func bishopAttacks(pos Position, occupied bitboard) bitboard {
+	return slidingAttacks(pos, occupied, bishopDirections)
+}
+
+// togglePiece adds or removes a piece of the bitboards.
+func (b *Board) togglePiece(p *Piece, pos Position) {
+	c := colorIndex(p.color)
+	bb := squareBB(pos)
+
+	b.pieces[c][p.kind] ^= bb
+	b.colors[c] ^= bb
+}
+
+// setBitboards computes the bitboards from the squares.
+func (b *Board) setBitboards() {
+	b.pieces = [2][7]bitboard{}
+	b.colors = [2]bitboard{}
+
+	for i, p := range b.squares {
+		if p != nil && p.kind != Empty {
+			b.togglePiece(p, Position(i))
+		}
+	}
+}
+
+// setPiece puts a piece on a square, nil emptying it.
+func (b *Board) setPiece(pos Position, p *Piece) {
+	if old := b.squares[pos]; old != nil && old.kind != Empty {
+		b.togglePiece(old, pos)
+	}
+
+	b.squares[pos] = p
+	if p != nil && p.kind != Empty {
+		b.togglePiece(p, pos)
+	}
+
+	b.movesCache = nil
+}
+
+func (b *Board) occupied() bitboard {
+	return b.colors[0] | b.colors[1]
+}
+
+// attackers returns the pieces of the given color attacking the square, the
+// sliding pieces being blocked by the occupied squares.
+func (b *Board) attackers(pos Position, color Color,
+	occupied bitboard) bitboard {
+	c := colorIndex(color)
+	p := &b.pieces[c]
+
+	// A pawn attacks the squares a pawn of the other color would be
+	// attacked from.
+	return knightAttacks[pos]&p[Knight] |
+		kingAttacks[pos]&p[King] |
+		pawnAttacks[1-c][pos]&p[Pawn] |
+		bishopAttacks(pos, occupied)&(p[Bishop]|p[Queen]) |
+		rookAttacks(pos, occupied)&(p[Rook]|p[Queen])
+}
+
+// pinned returns the pieces of the given color that cannot leave the line
+// between their king and an enemy sliding piece without exposing the king.
+func (b *Board) pinned(color Color) bitboard {
+	c := colorIndex(color)
+	if b.pieces[c][King] == 0 {
+		return 0
+	}
+
+	king := b.pieces[c][King].first()
+	them := &b.pieces[1-c]
+	occupied := b.occupied()
+
+	// Enemy sliding pieces that would attack the king on an empty board.
+	snipers := rookAttacks(king, 0)&(them[Rook]|them[Queen]) |
+		bishopAttacks(king, 0)&(them[Bishop]|them[Queen])
+
+	var pinned bitboard
+
+	for snipers != 0 {
+		blockers := between[king][snipers.pop()] & occupied
+		if blockers.count() == 1 && blockers&b.colors[c] != 0 {
+			pinned |= blockers
+		}
+	}
+
+	return pinned
+}
+
+func (b *Board) isSquareAttacked(pos Position, color Color) bool {
+	return b.attackers(pos, color, b.occupied()) != 0
+}
+
+// isKingAttacked returns true if the king of the given color is attacked,
+// false if there is no such king.
+func (b *Board) isKingAttacked(color Color) bool {
+	king := b.pieces[colorIndex(color)][King]
+	if king == 0 {
+		return false
+	}
+
+	return b.isSquareAttacked(king.first(), !color)
+}
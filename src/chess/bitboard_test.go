@@ -0,0 +1,123 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestBitboardLeaperAttacks(t *testing.T) {
+	tests := []struct {
+		attacks bitboard
+		count   int
+	}{
+		{knightAttacks[0], 2},
+		{knightAttacks[27], 8},
+		{knightAttacks[63], 2},
+		{kingAttacks[0], 3},
+		{kingAttacks[35], 8},
+		{pawnAttacks[0][52], 2},
+		{pawnAttacks[0][48], 1},
+		{pawnAttacks[1][15], 1},
+	}
+
+	for i, test := range tests {
+		if test.attacks.count() != test.count {
+			t.Errorf("test %d: expected %d squares instead of %d",
+				i, test.count, test.attacks.count())
+		}
+	}
+
+	// The e2 pawn attacks d3 and f3.
+	if !pawnAttacks[0][52].has(43) || !pawnAttacks[0][52].has(45) {
+		t.Fatalf("bad white pawn attacks")
+	}
+	// The e7 pawn attacks d6 and f6.
+	if !pawnAttacks[1][12].has(19) || !pawnAttacks[1][12].has(21) {
+		t.Fatalf("bad black pawn attacks")
+	}
+}
+
+func TestBitboardSlidingAttacks(t *testing.T) {
+	// Rook on d5 (27) with blockers on d7 (11) and f5 (29).
+	occupied := squareBB(27) | squareBB(11) | squareBB(29)
+
+	attacks := rookAttacks(27, occupied)
+	if attacks.count() != 2+4+3+2 {
+		t.Fatalf("expected 11 rook squares instead of %d", attacks.count())
+	}
+	if !attacks.has(11) || attacks.has(3) || !attacks.has(29) ||
+		attacks.has(30) || !attacks.has(59) || !attacks.has(24) {
+		t.Fatalf("bad rook attacks")
+	}
+
+	// Bishop on a8 (0) blocked on c6 (18).
+	attacks = bishopAttacks(0, squareBB(18))
+	if attacks != squareBB(9)|squareBB(18) {
+		t.Fatalf("bad bishop attacks")
+	}
+
+	if bishopAttacks(27, 0).count() != 13 || rookAttacks(27, 0).count() != 14 {
+		t.Fatalf("bad attacks on an empty board")
+	}
+}
+
+func TestBitboardPop(t *testing.T) {
+	bb := squareBB(3) | squareBB(40) | squareBB(63)
+
+	var squares []Position
+	for bb != 0 {
+		squares = append(squares, bb.pop())
+	}
+
+	if len(squares) != 3 ||
+		squares[0] != 3 || squares[1] != 40 || squares[2] != 63 {
+		t.Fatalf("unexpected squares %v", squares)
+	}
+}
+
+func TestBitboardAttackers(t *testing.T) {
+	b, _ := NewBoardFromFEN("4k3/8/8/3p4/8/1BN5/3R4/3QK3 w - - 0 1")
+
+	// d5 is attacked by the knight, the bishop and the rook, not by the
+	// queen that is blocked by the rook.
+	attackers := b.attackers(27, White, b.occupied())
+	if attackers.count() != 3 || attackers.has(59) {
+		t.Fatalf("expected 3 attackers instead of %d", attackers.count())
+	}
+
+	// e4 is attacked by the d5 pawn.
+	if b.attackers(36, Black, b.occupied()) != squareBB(27) {
+		t.Fatalf("e4 should be attacked by the d5 pawn")
+	}
+
+	if b.isCheck() {
+		t.Fatalf("white should not be in check")
+	}
+
+	b.setPiece(52, &Piece{kind: Rook, color: Black})
+	if !b.isCheck() {
+		t.Fatalf("white should be in check")
+	}
+}
+
+func TestBitboardPinned(t *testing.T) {
+	// The e2 rook is pinned by the e8 rook, the d2 knight is not pinned as
+	// the b4 bishop is blocked by the c3 pawn.
+	b, err := NewBoardFromFEN("k3r3/8/8/8/1b6/2P5/3NR3/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pinned := b.pinned(White); pinned != squareBB(52) {
+		t.Fatalf("expected the e2 rook to be pinned instead of %x", pinned)
+	}
+
+	if between[60][4].count() != 6 || between[60][4].has(4) {
+		t.Fatalf("bad squares between e1 and e8")
+	}
+	if between[60][5] != 0 || lines[60][5] != 0 {
+		t.Fatalf("e1 and f8 are not aligned")
+	}
+	if !lines[60][51].has(33) || !lines[60][51].has(60) {
+		t.Fatalf("bad line going through e1 and d2")
+	}
+}
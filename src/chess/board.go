@@ -0,0 +1,1140 @@
+package chess
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Board is a chess position along with the moves played to reach it.
+type Board struct {
+	history map[uint64]int
+	squares [64]*Piece
+	turn    Color
+	nbMoves int
+
+	// Castling rights still available, see CastleWhiteKingSide...
+	castling uint8
+
+	// Initial square of the rook of each castling right, indexed like the
+	// castling rights bits.
+	castlingRooks [4]Position
+
+	// Chess960 boards encode castling as the king taking its own rook.
+	chess960 bool
+
+	// Square a pawn can be taken on en passant, 0 (a8) if the last move was
+	// not a pawn moving two squares forward.
+	enPassant Position
+
+	// Number of half-moves since the last capture or pawn move.
+	halfMoves int
+
+	// Zobrist key of the position.
+	key uint64
+
+	// Moves played in place, to be taken back.
+	undo []undoInfo
+
+	// Pieces by color and kind, and all the pieces of each color.
+	pieces [2][7]bitboard
+	colors [2]bitboard
+
+	movesCache Moves
+}
+
+// undoInfo holds what cannot be deduced from a move to take it back.
+type undoInfo struct {
+	move Move
+
+	// Piece taken by the move, if any, and its square (different from the
+	// destination square for en passant).
+	captured    *Piece
+	capturedPos Position
+
+	// Castling move, if the move was castling.
+	castled bool
+	castle  castlingSquares
+
+	castling  uint8
+	enPassant Position
+	halfMoves int
+	key       uint64
+
+	movesCache Moves
+	pieces     [2][7]bitboard
+	colors     [2]bitboard
+}
+
+// NewBoard returns a board set up with the standard starting position.
+func NewBoard() *Board {
+	turn := White
+	board := &Board{
+		turn:    turn,
+		history: make(map[uint64]int),
+	}
+
+	board.initPieces(standardBackRank)
+	board.setBitboards()
+	board.setInitialCastlingRights()
+	board.key = board.computeKey()
+
+	h := board.hash()
+	board.history[h] = 1
+
+	return board
+}
+
+// NewEmptyBoard returns a board without any piece, white to move.
+func NewEmptyBoard() *Board {
+	turn := White
+	b := &Board{
+		turn:          turn,
+		history:       make(map[uint64]int),
+		castlingRooks: standardCastlingRooks,
+	}
+	b.key = b.computeKey()
+
+	return b
+}
+
+// NewBoardFromDiagram returns the board of a diagram as printed by Dump, with
+// the given side to move, or an error if the diagram is malformed or the
+// position invalid (see Validate).
+func NewBoardFromDiagram(diag string, turn Color) (*Board, error) {
+	b := NewEmptyBoard()
+	b.turn = turn
+
+	diag = strings.TrimSpace(diag)
+	var pos Position
+
+	for _, r := range diag {
+		var piece *Piece
+
+		switch r {
+		case ' ':
+			// Empty square
+		case 'k':
+			piece = &Piece{kind: King, color: White}
+		case 'K':
+			piece = &Piece{kind: King, color: Black}
+		case 'q':
+			piece = &Piece{kind: Queen, color: White}
+		case 'Q':
+			piece = &Piece{kind: Queen, color: Black}
+		case 'r':
+			piece = &Piece{kind: Rook, color: White}
+		case 'R':
+			piece = &Piece{kind: Rook, color: Black}
+		case 'b':
+			piece = &Piece{kind: Bishop, color: White}
+		case 'B':
+			piece = &Piece{kind: Bishop, color: Black}
+		case 'n':
+			piece = &Piece{kind: Knight, color: White}
+		case 'N':
+			piece = &Piece{kind: Knight, color: Black}
+		case 'p':
+			piece = &Piece{kind: Pawn, color: White}
+		case 'P':
+			piece = &Piece{kind: Pawn, color: Black}
+		default:
+			continue
+		}
+
+		if pos == 64 {
+			return nil, fmt.Errorf("invalid diagram: more than 64 squares")
+		}
+
+		b.squares[pos] = piece
+		pos++
+	}
+
+	if pos != 64 {
+		return nil, fmt.Errorf("invalid diagram: expected 64 squares "+
+			"instead of %d", pos)
+	}
+
+	b.setBitboards()
+	b.setInitialCastlingRights()
+	b.key = b.computeKey()
+
+	if err := b.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid diagram: %v", err)
+	}
+
+	return b, nil
+}
+
+// Clone returns a copy of the board, including its history.
+func (b *Board) Clone() *Board {
+	newBoard := &Board{
+		turn:      b.turn,
+		nbMoves:   b.nbMoves,
+		castling:  b.castling,
+		enPassant: b.enPassant,
+		halfMoves: b.halfMoves,
+		key:       b.key,
+		pieces:    b.pieces,
+		colors:    b.colors,
+
+		castlingRooks: b.castlingRooks,
+		chess960:      b.chess960,
+	}
+
+	newBoard.history = make(map[uint64]int)
+	for key, val := range b.history {
+		newBoard.history[key] = val
+	}
+
+	for key, val := range b.squares {
+		if val == nil {
+			continue
+		}
+
+		newBoard.squares[key] = val.clone()
+	}
+
+	newBoard.undo = make([]undoInfo, len(b.undo))
+	for i, u := range b.undo {
+		newBoard.undo[i] = u
+		newBoard.undo[i].movesCache = nil
+
+		if u.captured != nil {
+			newBoard.undo[i].captured = u.captured.clone()
+		}
+	}
+
+	return newBoard
+}
+
+// initPieces puts the pieces on their initial squares, the back rank being
+// given from the a-file to the h-file.
+func (b *Board) initPieces(backRank [8]PieceType) {
+	for col, kind := range backRank {
+		b.squares[col] = &Piece{
+			kind:  kind,
+			color: Black,
+		}
+		b.squares[8+col] = &Piece{
+			kind:  Pawn,
+			color: Black,
+		}
+		b.squares[48+col] = &Piece{
+			kind:  Pawn,
+			color: White,
+		}
+		b.squares[56+col] = &Piece{
+			kind:  kind,
+			color: White,
+		}
+	}
+}
+
+// Turn returns the color of the side to move.
+func (b *Board) Turn() Color {
+	return b.turn
+}
+
+// PieceAt returns the piece standing on a square, false if it is empty.
+func (b *Board) PieceAt(pos Position) (Piece, bool) {
+	p := b.squares[pos]
+	if p == nil {
+		return Piece{}, false
+	}
+
+	return *p, true
+}
+
+// Key returns the Zobrist key of the position, the same for positions with
+// the same pieces, side to move, castling rights and en passant square.
+func (b *Board) Key() uint64 {
+	return b.key
+}
+
+// FullMoveNumber returns the number of the current move, starting at 1 and
+// incremented after each black move.
+func (b *Board) FullMoveNumber() int {
+	return b.nbMoves/2 + 1
+}
+
+// HalfMoveClock returns the number of half-moves since the last capture or
+// pawn move.
+func (b *Board) HalfMoveClock() int {
+	return b.halfMoves
+}
+
+// IsChess960 returns true if castling is encoded as the king taking its own
+// rook.
+func (b *Board) IsChess960() bool {
+	return b.chess960
+}
+
+// SetChess960 sets how castling is encoded, the king either moving two
+// squares or taking its own rook. The castling rooks are left unchanged.
+func (b *Board) SetChess960(chess960 bool) {
+	b.chess960 = chess960
+	b.movesCache = nil
+}
+
+func (b *Board) getDump() string {
+	buf := ""
+
+	for i, piece := range b.squares {
+		val := " "
+
+		if piece != nil {
+			val = piece.String()
+		}
+
+		buf = buf + "|" + val
+
+		if i%8 == 7 {
+			buf = buf + "|\n"
+		}
+	}
+
+	return buf
+}
+
+// Dump prints the board as a diagram on the standard output.
+func (b *Board) Dump() {
+	fmt.Println(b.getDump())
+}
+
+func (b *Board) hash() uint64 {
+	return b.key
+}
+
+func (b *Board) hasEnoughMaterial() bool {
+	lightBishops := 0
+	darkBishops := 0
+	knights := 0
+
+	for i, sq := range b.squares {
+		if sq == nil {
+			continue
+		}
+
+		pos := Position(i)
+
+		switch sq.kind {
+		case Pawn:
+			return true
+
+		case Knight:
+			knights++
+			if knights > 1 || lightBishops+darkBishops > 0 {
+				return true
+			}
+
+		case Bishop:
+			if (pos.getRow()+pos.getCol())%2 == 0 {
+				lightBishops++
+			} else {
+				darkBishops++
+			}
+			if (lightBishops > 0 && darkBishops > 0) || knights > 0 {
+				return true
+			}
+
+		case Rook:
+			return true
+
+		case Queen:
+			return true
+		}
+	}
+
+	return false
+}
+
+// Initial squares of the castling rooks on a standard board.
+var standardCastlingRooks = [4]Position{63, 56, 7, 0}
+
+// castlingSquares holds the squares of the king and the rook before and after
+// castling.
+type castlingSquares struct {
+	right    uint8
+	kingFrom Position
+	kingTo   Position
+	rookFrom Position
+	rookTo   Position
+}
+
+// setInitialCastlingRights gives the castling rights of the kings and rooks
+// standing on their initial squares. On Chess960 boards, the rooks are the
+// outermost ones on each side of the king.
+func (b *Board) setInitialCastlingRights() {
+	b.castling = 0
+	b.castlingRooks = standardCastlingRooks
+
+	for i, c := range fenCastling {
+		king := b.squares[c.king]
+		rook := b.squares[c.rook]
+
+		if b.chess960 {
+			var ok bool
+
+			rook = nil
+			king = b.backRankKing(c.color)
+			if king != nil {
+				b.castlingRooks[i], ok = b.outermostRook(c.color, c.kingSide)
+				if ok {
+					rook = b.squares[b.castlingRooks[i]]
+				}
+			}
+		}
+
+		if king != nil && king.kind == King && king.color == c.color &&
+			rook != nil && rook.kind == Rook && rook.color == c.color {
+			b.castling |= c.right
+		}
+	}
+}
+
+// backRankKing returns the king of the given color if it stands on its back
+// rank, nil otherwise.
+func (b *Board) backRankKing(color Color) *Piece {
+	kings := b.pieces[colorIndex(color)][King]
+	if kings.count() != 1 || kings.first().getRow() != backRank(color) {
+		return nil
+	}
+
+	return b.squares[kings.first()]
+}
+
+// outermostRook returns the rook of the given color that is the farthest from
+// the king on its side of the back rank.
+func (b *Board) outermostRook(color Color, kingSide bool) (Position, bool) {
+	king := b.getKingPosition(color)
+	row := Position(backRank(color) * 8)
+
+	col, end, step := 0, king.getCol(), 1
+	if kingSide {
+		col, end, step = 7, king.getCol(), -1
+	}
+
+	for ; col != end; col += step {
+		p := b.squares[row+Position(col)]
+		if p != nil && p.kind == Rook && p.color == color {
+			return row + Position(col), true
+		}
+	}
+
+	return 0, false
+}
+
+// backRank returns the row the pieces of the given color start on.
+func backRank(color Color) int {
+	if color == White {
+		return 7
+	}
+
+	return 0
+}
+
+// CastlingRights returns the castling rights still available, see
+// CastleWhiteKingSide...
+func (b *Board) CastlingRights() uint8 {
+	return b.castling
+}
+
+// EnPassantSquare returns the square a pawn can be taken on en passant, if
+// the last move was a pawn moving two squares forward.
+func (b *Board) EnPassantSquare() (Position, bool) {
+	return b.enPassant, b.enPassant != 0
+}
+
+// updateCastlingRights removes the castling rights lost by a move, either by
+// moving the king or the rook, or by taking the rook.
+func (b *Board) updateCastlingRights(move *Move, piece *Piece) {
+	for i, c := range fenCastling {
+		if (piece.kind == King && piece.color == c.color) ||
+			move.from == b.castlingRooks[i] || move.to == b.castlingRooks[i] {
+			b.castling &= ^c.right
+		}
+	}
+}
+
+// getCastlingSquares returns the squares of the king and the rook of the
+// castling right i, the king standing on the given square.
+func (b *Board) getCastlingSquares(i int, king Position) castlingSquares {
+	c := fenCastling[i]
+	row := Position(backRank(c.color) * 8)
+
+	// The king ends up on the g-file or the c-file, the rook right next to
+	// it toward the center.
+	kingTo, rookTo := row+6, row+5
+	if !c.kingSide {
+		kingTo, rookTo = row+2, row+3
+	}
+
+	return castlingSquares{
+		right:    c.right,
+		kingFrom: king,
+		kingTo:   kingTo,
+		rookFrom: b.castlingRooks[i],
+		rookTo:   rookTo,
+	}
+}
+
+// getCastling returns the squares of a castling move, false if the move is
+// not castling. Castling is the king moving two squares toward the rook, or
+// the king taking its own rook on Chess960 boards.
+func (b *Board) getCastling(move *Move) (castlingSquares, bool) {
+	king := b.squares[move.from]
+	if king == nil || king.kind != King {
+		return castlingSquares{}, false
+	}
+
+	for i, c := range fenCastling {
+		if c.color != king.color {
+			continue
+		}
+
+		castle := b.getCastlingSquares(i, move.from)
+
+		if b.chess960 {
+			if move.to == castle.rookFrom && b.squares[move.to] != nil &&
+				b.squares[move.to].kind == Rook &&
+				b.squares[move.to].color == king.color {
+				return castle, true
+			}
+		} else if move.to == castle.kingTo &&
+			(move.to-move.from == 2 || move.from-move.to == 2) {
+			return castle, true
+		}
+	}
+
+	return castlingSquares{}, false
+}
+
+// castlingMove returns the move the king plays to castle.
+func (b *Board) castlingMove(castle *castlingSquares) Move {
+	if b.chess960 {
+		return Move{from: castle.kingFrom, to: castle.rookFrom}
+	}
+
+	return Move{from: castle.kingFrom, to: castle.kingTo}
+}
+
+// canCastle returns true if the squares the king and the rook go through are
+// empty and if the king does not castle out of, through or into check.
+func (b *Board) canCastle(castle *castlingSquares) bool {
+	// The king and the rook can jump over each other.
+	occupied := b.occupied() &^
+		squareBB(castle.kingFrom) &^ squareBB(castle.rookFrom)
+
+	kingPath := between[castle.kingFrom][castle.kingTo] |
+		squareBB(castle.kingFrom) | squareBB(castle.kingTo)
+	rookPath := between[castle.rookFrom][castle.rookTo] |
+		squareBB(castle.rookTo)
+
+	if (kingPath|rookPath)&occupied != 0 {
+		return false
+	}
+
+	color := b.squares[castle.kingFrom].color
+
+	for kingPath != 0 {
+		if b.attackers(kingPath.pop(), !color, occupied) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (b *Board) getKingPosition(color Color) Position {
+	king := b.pieces[colorIndex(color)][King]
+	if king == 0 {
+		panic("no king")
+	}
+
+	return king.first()
+}
+
+func (b *Board) isKingTakable(move *Move) bool {
+	b.moveNoCheck(move)
+	defer b.unmoveNoCheck()
+
+	return b.isKingAttacked(!b.turn)
+}
+
+func (b *Board) isCheck() bool {
+	return b.InCheck()
+}
+
+// InCheck returns true if the king of the side to move is attacked.
+func (b *Board) InCheck() bool {
+	return b.checkers() != 0
+}
+
+// Checkers returns the positions of the pieces giving check to the side to
+// move, two at most.
+func (b *Board) Checkers() []Position {
+	var list []Position
+
+	for bb := b.checkers(); bb != 0; {
+		list = append(list, bb.pop())
+	}
+
+	return list
+}
+
+// checkers returns the pieces attacking the king of the side to move, none if
+// there is no such king.
+func (b *Board) checkers() bitboard {
+	king := b.pieces[colorIndex(b.turn)][King]
+	if king == 0 {
+		return 0
+	}
+
+	return b.attackers(king.first(), !b.turn, b.occupied())
+}
+
+func (b *Board) getPawnMoves(pos Position) []Move {
+	var moves Moves
+
+	piece := b.squares[pos]
+	c := colorIndex(piece.color)
+	empty := ^b.occupied()
+
+	// Forward
+	forward, startRow, enPassantRow := pos-8, 6, 2
+	if piece.color == Black {
+		forward, startRow, enPassantRow = pos+8, 1, 5
+	}
+
+	if empty.has(forward) {
+		moves.appendPawnMove(b, pos, forward)
+
+		double := 2*forward - pos
+		if pos.getRow() == startRow && empty.has(double) {
+			moves.appendPawnMove(b, pos, double)
+		}
+	}
+
+	// Takes, including en passant
+	targets := b.colors[1-c]
+	if b.enPassant != 0 && b.enPassant.getRow() == enPassantRow {
+		targets |= squareBB(b.enPassant)
+	}
+
+	for bb := pawnAttacks[c][pos] & targets; bb != 0; {
+		moves.appendPawnMove(b, pos, bb.pop())
+	}
+
+	return moves
+}
+
+// appendTargets appends the moves from pos to each target square.
+func (b *Board) appendTargets(pos Position, targets bitboard, moves *Moves) {
+	for targets != 0 {
+		moves.Append(b, pos, targets.pop())
+	}
+}
+
+// getTargets returns the squares a piece can move to, without castling and
+// pawn moves.
+func (b *Board) getTargets(pos Position, kind PieceType) bitboard {
+	var targets bitboard
+
+	switch kind {
+	case Knight:
+		targets = knightAttacks[pos]
+	case Bishop:
+		targets = bishopAttacks(pos, b.occupied())
+	case Rook:
+		targets = rookAttacks(pos, b.occupied())
+	case Queen:
+		targets = bishopAttacks(pos, b.occupied()) |
+			rookAttacks(pos, b.occupied())
+	case King:
+		targets = kingAttacks[pos]
+	}
+
+	return targets &^ b.colors[colorIndex(b.squares[pos].color)]
+}
+
+func (b *Board) getKnightMoves(pos Position) []Move {
+	var moves Moves
+	b.appendTargets(pos, b.getTargets(pos, Knight), &moves)
+
+	return moves
+}
+
+func (b *Board) getRookMoves(pos Position) []Move {
+	var moves Moves
+	b.appendTargets(pos, b.getTargets(pos, Rook), &moves)
+
+	return moves
+}
+
+func (b *Board) getBishopMoves(pos Position) []Move {
+	var moves Moves
+	b.appendTargets(pos, b.getTargets(pos, Bishop), &moves)
+
+	return moves
+}
+
+func (b *Board) getQueenMoves(pos Position) []Move {
+	var moves Moves
+	b.appendTargets(pos, b.getTargets(pos, Queen), &moves)
+
+	return moves
+}
+
+func (b *Board) getKingMoves(pos Position) []Move {
+	var moves Moves
+	b.appendTargets(pos, b.getTargets(pos, King), &moves)
+	b.appendCastling(pos, &moves)
+
+	return moves
+}
+
+// appendCastling appends the castling moves of the king, which cannot castle
+// out of, through or into check.
+func (b *Board) appendCastling(pos Position, moves *Moves) {
+	color := b.squares[pos].color
+
+	for i, c := range fenCastling {
+		if c.color != color || b.castling&c.right == 0 {
+			continue
+		}
+
+		castle := b.getCastlingSquares(i, pos)
+		if b.canCastle(&castle) {
+			*moves = append(*moves, b.castlingMove(&castle))
+		}
+	}
+}
+
+func (b *Board) getMovesOpts(legal bool, kingMoves bool, cache bool) Moves {
+	if cache && b.movesCache != nil {
+		return b.movesCache
+	}
+
+	if legal && b.pieces[colorIndex(b.turn)][King] != 0 {
+		moves := b.getLegalMoves()
+		if cache == true {
+			b.movesCache = moves
+		}
+
+		return moves
+	}
+
+	moves := make(Moves, 0, 64)
+
+	for own := b.colors[colorIndex(b.turn)]; own != 0; {
+		pos := own.pop()
+		square := b.squares[pos]
+
+		switch square.kind {
+		case Pawn:
+			moves = append(moves, b.getPawnMoves(pos)...)
+
+		case Knight, Bishop, Rook, Queen:
+			b.appendTargets(pos, b.getTargets(pos, square.kind), &moves)
+
+		case King:
+			if kingMoves == true {
+				moves = append(moves, b.getKingMoves(pos)...)
+			}
+
+		default:
+			log.Panicf("unknown type: %d", square.kind)
+		}
+	}
+
+	// Without a king, every move is legal.
+	return moves
+}
+
+// getLegalMoves only generates legal moves, the checkers and the pinned
+// pieces being computed once for the whole position instead of playing each
+// move to see if it leaves the king in check. The side to move must have a
+// king.
+func (b *Board) getLegalMoves() Moves {
+	moves := make(Moves, 0, 64)
+
+	c := colorIndex(b.turn)
+	king := b.pieces[c][King].first()
+	occupied := b.occupied()
+
+	// The king cannot hide behind itself from a sliding piece.
+	for targets := b.getTargets(king, King); targets != 0; {
+		to := targets.pop()
+		if b.attackers(to, !b.turn, occupied^squareBB(king)) == 0 {
+			moves.Append(b, king, to)
+		}
+	}
+
+	checkers := b.checkers()
+	if checkers.count() > 1 {
+		// Double check, only the king can move.
+		return moves
+	}
+
+	// Squares the other pieces can move to: anywhere, or to take or block
+	// the checking piece.
+	evasions := ^bitboard(0)
+	if checkers != 0 {
+		evasions = checkers | between[king][checkers.first()]
+	} else {
+		b.appendCastling(king, &moves)
+	}
+
+	pinned := b.pinned(b.turn)
+
+	for own := b.colors[c] &^ squareBB(king); own != 0; {
+		pos := own.pop()
+		square := b.squares[pos]
+
+		allowed := evasions
+		if pinned.has(pos) {
+			allowed &= lines[king][pos]
+		}
+
+		switch square.kind {
+		case Pawn:
+			for _, move := range b.getPawnMoves(pos) {
+				if b.enPassant != 0 && move.to == b.enPassant &&
+					b.squares[move.to] == nil {
+					// Taking en passant removes two pieces from the
+					// rank of the king, it is simply played.
+					if !b.isKingTakable(&move) {
+						moves = append(moves, move)
+					}
+				} else if allowed.has(move.to) {
+					moves = append(moves, move)
+				}
+			}
+
+		case Knight, Bishop, Rook, Queen:
+			b.appendTargets(pos, b.getTargets(pos, square.kind)&allowed,
+				&moves)
+
+		default:
+			log.Panicf("unknown type: %d", square.kind)
+		}
+	}
+
+	return moves
+}
+
+// GetMoves returns the legal moves of the side to move, empty if it is
+// checkmated or stalemated.
+func (b *Board) GetMoves() Moves {
+	return b.getMovesOpts(true, true, true)
+}
+
+// GetMovesOf returns the legal moves the given color would have if it were
+// its turn to move.
+func (b *Board) GetMovesOf(color Color) Moves {
+	if color == b.turn {
+		return b.GetMoves()
+	}
+
+	b.turn.swap()
+	defer b.turn.swap()
+
+	return b.getMovesOpts(true, true, false)
+}
+
+// Move plays the move and returns the state of the game. Claimable draws are
+// returned as long as they can be claimed, the game going on if the draw is
+// not claimed (see State.IsClaimable).
+func (b *Board) Move(move *Move) State {
+	b.MakeMove(move)
+
+	return b.State()
+}
+
+// MakeMove plays the move in place, without computing the state of the game.
+// It can be taken back with UnmakeMove.
+func (b *Board) MakeMove(move *Move) {
+	b.moveNoCheck(move)
+	b.history[b.hash()]++
+}
+
+// UnmakeMove takes back the last move played by MakeMove or Move.
+func (b *Board) UnmakeMove() {
+	h := b.hash()
+	b.history[h]--
+	if b.history[h] <= 0 {
+		delete(b.history, h)
+	}
+
+	b.unmoveNoCheck()
+}
+
+// MakeNullMove passes the turn to the other side without moving, which the
+// rules do not allow but the search uses to find out if a position is good
+// enough even without moving. It must not be played when in check, and must
+// be taken back with UnmakeNullMove.
+func (b *Board) MakeNullMove() {
+	b.undo = append(b.undo, undoInfo{
+		enPassant:  b.enPassant,
+		halfMoves:  b.halfMoves,
+		key:        b.key,
+		movesCache: b.movesCache,
+	})
+
+	b.movesCache = nil
+
+	b.key ^= b.zobristEnPassantKey() ^ zobristBlack
+	b.enPassant = 0
+	b.halfMoves++
+
+	b.turn.swap()
+	b.nbMoves++
+}
+
+// UnmakeNullMove takes back the last null move played by MakeNullMove.
+func (b *Board) UnmakeNullMove() {
+	u := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+
+	b.turn.swap()
+	b.nbMoves--
+
+	b.enPassant = u.enPassant
+	b.halfMoves = u.halfMoves
+	b.key = u.key
+	b.movesCache = u.movesCache
+}
+
+// Undo takes back the last move, it returns an error if no move has been
+// played since the initial position of the board.
+func (b *Board) Undo() error {
+	if len(b.undo) == 0 {
+		return fmt.Errorf("no move to take back")
+	}
+
+	b.UnmakeMove()
+
+	return nil
+}
+
+// Moves returns the moves played since the initial position of the board.
+func (b *Board) Moves() Moves {
+	moves := make(Moves, len(b.undo))
+	for i := range b.undo {
+		moves[i] = b.undo[i].move
+	}
+
+	return moves
+}
+
+// PositionAt returns a copy of the board once the given number of moves have
+// been played from the initial position, 0 being the initial position.
+func (b *Board) PositionAt(ply int) (*Board, error) {
+	if ply < 0 || ply > len(b.undo) {
+		return nil, fmt.Errorf("no position at ply %d, %d moves played",
+			ply, len(b.undo))
+	}
+
+	position := b.Clone()
+	for len(position.undo) > ply {
+		position.UnmakeMove()
+	}
+
+	return position, nil
+}
+
+// State returns the state of the game in the current position, as returned by
+// the move leading to it.
+func (b *Board) State() State {
+	repetitions := b.history[b.hash()]
+
+	m := b.GetMoves()
+	if len(m) == 0 {
+		// Checkmate takes precedence over the other draws.
+		if !b.isCheck() {
+			return StateDrawByStalemate
+		}
+
+		if b.turn == White {
+			return StateBlackWins
+		}
+
+		return StateWhiteWins
+	}
+
+	switch {
+	case b.hasEnoughMaterial() == false:
+		return StateDrawByInsufficientMaterial
+	case repetitions >= 5:
+		return StateDrawByFivefoldRepetition
+	case b.halfMoves >= 150:
+		return StateDrawBySeventyFiveMoves
+	case repetitions >= 3:
+		return StateDrawByRepetition
+	case b.halfMoves >= 100:
+		return StateDrawByFiftyMoves
+	}
+
+	return StatePlaying
+}
+
+func (b *Board) moveNoCheck(move *Move) {
+	u := undoInfo{
+		move:        *move,
+		captured:    b.squares[move.to],
+		capturedPos: move.to,
+		castling:    b.castling,
+		enPassant:   b.enPassant,
+		halfMoves:   b.halfMoves,
+		key:         b.key,
+		movesCache:  b.movesCache,
+		pieces:      b.pieces,
+		colors:      b.colors,
+	}
+
+	b.movesCache = nil
+
+	// Castling rights and en passant square are put back once the move is
+	// done.
+	b.key ^= zobristCastling[b.CastlingRights()] ^ b.zobristEnPassantKey()
+
+	if castle, ok := b.getCastling(move); ok {
+		u.captured = nil
+		u.castled = true
+		u.castle = castle
+
+		b.castle(&castle)
+		b.enPassant = 0
+		b.halfMoves++
+	} else {
+		b.movePiece(move, &u)
+	}
+
+	b.turn.swap()
+	b.nbMoves++
+
+	b.key ^= zobristBlack
+	b.key ^= zobristCastling[b.CastlingRights()] ^ b.zobristEnPassantKey()
+
+	b.undo = append(b.undo, u)
+}
+
+// movePiece plays a move that is not castling.
+func (b *Board) movePiece(move *Move, u *undoInfo) {
+	isTake := b.squares[move.to] != nil
+	if isTake {
+		b.key ^= zobristPiece(b.squares[move.to], move.to)
+		b.togglePiece(b.squares[move.to], move.to)
+	}
+
+	piece := b.squares[move.from]
+	b.key ^= zobristPiece(piece, move.from)
+	b.togglePiece(piece, move.from)
+
+	b.squares[move.to] = piece
+	b.squares[move.from] = nil
+
+	if move.promoteTo != Empty {
+		piece.kind = move.promoteTo
+	}
+	b.key ^= zobristPiece(piece, move.to)
+	b.togglePiece(piece, move.to)
+
+	b.updateCastlingRights(move, piece)
+	b.enPassant = 0
+
+	if piece.kind == Pawn || isTake {
+		b.halfMoves = 0
+	} else {
+		b.halfMoves++
+	}
+
+	if piece.kind == Pawn {
+		if move.to-move.from == 16 || move.from-move.to == 16 {
+			b.enPassant = (move.from + move.to) / 2
+		}
+
+		// En passant
+		if (move.to-move.from)%8 != 0 && isTake == false {
+			taken := move.to - 8
+			if piece.color == White {
+				taken = move.to + 8
+			}
+
+			u.captured = b.squares[taken]
+			u.capturedPos = taken
+
+			b.key ^= zobristPiece(b.squares[taken], taken)
+			b.togglePiece(b.squares[taken], taken)
+			b.squares[taken] = nil
+		}
+	}
+}
+
+// castle moves the king and the rook, which may land on each other's
+// initial square.
+func (b *Board) castle(castle *castlingSquares) {
+	king := b.squares[castle.kingFrom]
+	rook := b.squares[castle.rookFrom]
+
+	b.key ^= zobristPiece(king, castle.kingFrom) ^
+		zobristPiece(king, castle.kingTo) ^
+		zobristPiece(rook, castle.rookFrom) ^
+		zobristPiece(rook, castle.rookTo)
+
+	b.togglePiece(king, castle.kingFrom)
+	b.togglePiece(rook, castle.rookFrom)
+	b.togglePiece(king, castle.kingTo)
+	b.togglePiece(rook, castle.rookTo)
+
+	b.squares[castle.kingFrom] = nil
+	b.squares[castle.rookFrom] = nil
+	b.squares[castle.kingTo] = king
+	b.squares[castle.rookTo] = rook
+
+	for _, c := range fenCastling {
+		if c.color == king.color {
+			b.castling &= ^c.right
+		}
+	}
+}
+
+// unmoveNoCheck takes back the last move played by moveNoCheck.
+func (b *Board) unmoveNoCheck() {
+	u := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+
+	move := &u.move
+
+	if u.castled {
+		king := b.squares[u.castle.kingTo]
+		rook := b.squares[u.castle.rookTo]
+
+		b.squares[u.castle.kingTo] = nil
+		b.squares[u.castle.rookTo] = nil
+		b.squares[u.castle.kingFrom] = king
+		b.squares[u.castle.rookFrom] = rook
+	} else {
+		piece := b.squares[move.to]
+
+		b.squares[move.from] = piece
+		b.squares[move.to] = nil
+		b.squares[u.capturedPos] = u.captured
+
+		if move.promoteTo != Empty {
+			piece.kind = Pawn
+		}
+	}
+
+	b.turn.swap()
+	b.nbMoves--
+
+	b.castling = u.castling
+	b.enPassant = u.enPassant
+	b.halfMoves = u.halfMoves
+	b.key = u.key
+	b.movesCache = u.movesCache
+	b.pieces = u.pieces
+	b.colors = u.colors
+}
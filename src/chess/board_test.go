@@ -1,7 +1,10 @@
-package geneticchess
+package chess
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -38,9 +41,18 @@ func checkMoves(t *testing.T, moves []Move, expected []Move, errStr string) {
 	}
 }
 
+func newBoardFromDiagram(t *testing.T, diagram string, turn Color) *Board {
+	b, err := NewBoardFromDiagram(diagram, turn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	return b
+}
+
 func TestBoardClone(t *testing.T) {
 	b := NewBoard()
-	b2 := b.clone()
+	b2 := b.Clone()
 
 	if b.hash() != b2.hash() {
 		t.Fatalf("cloned board does not match original one")
@@ -60,6 +72,42 @@ func TestBoardClone(t *testing.T) {
 	}
 }
 
+func TestBoardAccessors(t *testing.T) {
+	b, err := NewBoardFromFEN(
+		"rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2")
+	if err != nil {
+		t.Fatalf("invalid fen: %v", err)
+	}
+
+	if b.Turn() != Black || b.FullMoveNumber() != 2 || b.HalfMoveClock() != 0 {
+		t.Fatalf("unexpected turn or move counters")
+	}
+	if b.CastlingRights() != CastleWhiteKingSide|CastleWhiteQueenSide|
+		CastleBlackKingSide|CastleBlackQueenSide || b.IsChess960() {
+		t.Fatalf("unexpected castling rights %b", b.CastlingRights())
+	}
+
+	piece, ok := b.PieceAt(28)
+	if !ok || piece.Kind() != Pawn || piece.Color() != White {
+		t.Fatalf("expected a white pawn on e5 instead of %v", piece)
+	}
+	if _, ok := b.PieceAt(36); ok {
+		t.Fatalf("expected e4 to be empty")
+	}
+
+	move := NewMove(13, 29)
+	b.Move(&move)
+
+	if pos, ok := b.EnPassantSquare(); !ok || pos != 21 {
+		t.Fatalf("expected en passant square f6 instead of %d", pos)
+	}
+
+	move = NewMove(28, 21)
+	if !move.IsCapture(b) || move.Captured(b) != Pawn {
+		t.Fatalf("expected exf6 to capture a pawn")
+	}
+}
+
 func TestBoardNewBoardFromDiagram(t *testing.T) {
 	diagram := "|R|N|B|Q|K|B|N|R|\n" +
 		"|P|P|P|P|P|P|P|P|\n" +
@@ -69,43 +117,43 @@ func TestBoardNewBoardFromDiagram(t *testing.T) {
 		"| | | | | | | | |\n" +
 		"|p|p|p|p|p|p|p|p|\n" +
 		"|r|n|b|q|k|b|n|r|\n"
-	b := NewBoardFromDiagram(diagram)
+	b := newBoardFromDiagram(t, diagram, White)
 
 	if b.getDump() != diagram {
 		t.Fatalf("expected %s instead of %s",
-			diagram, b.hash())
+			diagram, b.getDump())
 	}
 }
 
 func TestBoardKnightMoves(t *testing.T) {
 	b := NewEmptyBoard()
 
-	b.squares[0] = &Piece{
+	b.setPiece(0, &Piece{
 		kind:  Knight,
 		color: White,
-	}
+	})
 	m := b.getKnightMoves(0)
 	checkMoves(t, m, []Move{
 		Move{from: 0, to: 10, promoteTo: 0},
 		Move{from: 0, to: 17, promoteTo: 0},
 	}, "bad knight move 1")
-	b.squares[0] = &Piece{}
+	b.setPiece(0, nil)
 
-	b.squares[7] = &Piece{
+	b.setPiece(7, &Piece{
 		kind:  Knight,
 		color: White,
-	}
+	})
 	m = b.getKnightMoves(7)
 	checkMoves(t, m, []Move{
 		Move{from: 7, to: 13, promoteTo: 0},
 		Move{from: 7, to: 22, promoteTo: 0},
 	}, "bad knight move 2")
-	b.squares[7] = &Piece{}
+	b.setPiece(7, nil)
 
-	b.squares[27] = &Piece{
+	b.setPiece(27, &Piece{
 		kind:  Knight,
 		color: White,
-	}
+	})
 	m = b.getKnightMoves(27)
 	checkMoves(t, m, []Move{
 		Move{from: 27, to: 17, promoteTo: 0},
@@ -117,37 +165,37 @@ func TestBoardKnightMoves(t *testing.T) {
 		Move{from: 27, to: 42, promoteTo: 0},
 		Move{from: 27, to: 33, promoteTo: 0},
 	}, "bad knight move 3")
-	b.squares[27] = &Piece{}
+	b.setPiece(27, nil)
 
-	b.squares[56] = &Piece{
+	b.setPiece(56, &Piece{
 		kind:  Knight,
 		color: White,
-	}
+	})
 	m = b.getKnightMoves(56)
 	checkMoves(t, m, []Move{
 		Move{from: 56, to: 41, promoteTo: 0},
 		Move{from: 56, to: 50, promoteTo: 0},
 	}, "bad knight move 4")
-	b.squares[56] = &Piece{}
+	b.setPiece(56, nil)
 
-	b.squares[63] = &Piece{
+	b.setPiece(63, &Piece{
 		kind:  Knight,
 		color: White,
-	}
+	})
 	m = b.getKnightMoves(63)
 	checkMoves(t, m, []Move{
 		Move{from: 63, to: 53, promoteTo: 0},
 		Move{from: 63, to: 46, promoteTo: 0},
 	}, "bad knight move 5")
-	b.squares[63] = &Piece{}
+	b.setPiece(63, nil)
 }
 
 func TestBoardRookMoves(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[27] = &Piece{
+	b.setPiece(27, &Piece{
 		kind:  Rook,
 		color: White,
-	}
+	})
 	m := b.getRookMoves(27)
 
 	checkMoves(t, m, []Move{
@@ -173,22 +221,22 @@ func TestBoardRookMoves(t *testing.T) {
 
 func TestBoardRookMovesWithFriendlyPiecesAround(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[27] = &Piece{
+	b.setPiece(27, &Piece{
 		kind:  Rook,
 		color: White,
-	}
-	b.squares[26] = &Piece{
+	})
+	b.setPiece(26, &Piece{
 		kind:  Pawn,
 		color: White,
-	}
-	b.squares[19] = &Piece{
+	})
+	b.setPiece(19, &Piece{
 		kind:  Pawn,
 		color: White,
-	}
-	b.squares[28] = &Piece{
+	})
+	b.setPiece(28, &Piece{
 		kind:  Pawn,
 		color: White,
-	}
+	})
 	m := b.getRookMoves(27)
 
 	checkMoves(t, m, []Move{
@@ -201,22 +249,22 @@ func TestBoardRookMovesWithFriendlyPiecesAround(t *testing.T) {
 
 func TestBoardRookMovesWithEnemyPiecesAround(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[27] = &Piece{
+	b.setPiece(27, &Piece{
 		kind:  Rook,
 		color: White,
-	}
-	b.squares[26] = &Piece{
+	})
+	b.setPiece(26, &Piece{
 		kind:  Pawn,
 		color: Black,
-	}
-	b.squares[19] = &Piece{
+	})
+	b.setPiece(19, &Piece{
 		kind:  Pawn,
 		color: Black,
-	}
-	b.squares[28] = &Piece{
+	})
+	b.setPiece(28, &Piece{
 		kind:  Pawn,
 		color: Black,
-	}
+	})
 	m := b.getRookMoves(27)
 
 	checkMoves(t, m, []Move{
@@ -235,10 +283,10 @@ func TestBoardRookMovesWithEnemyPiecesAround(t *testing.T) {
 
 func TestBoardBishopMoves(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[27] = &Piece{
+	b.setPiece(27, &Piece{
 		kind:  Bishop,
 		color: White,
-	}
+	})
 	m := b.getBishopMoves(27)
 
 	checkMoves(t, m, []Move{
@@ -263,14 +311,14 @@ func TestBoardBishopMoves(t *testing.T) {
 
 func TestBoardBishopMovesWithEnemyPieceAround(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[40] = &Piece{
+	b.setPiece(40, &Piece{
 		kind:  Bishop,
 		color: White,
-	}
-	b.squares[26] = &Piece{
+	})
+	b.setPiece(26, &Piece{
 		kind:  Pawn,
 		color: Black,
-	}
+	})
 	m := b.getBishopMoves(40)
 
 	checkMoves(t, m, []Move{
@@ -284,10 +332,10 @@ func TestBoardBishopMovesWithEnemyPieceAround(t *testing.T) {
 
 func TestBoardQueenMoves(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[9] = &Piece{
+	b.setPiece(9, &Piece{
 		kind:  Queen,
 		color: White,
-	}
+	})
 	m := b.getQueenMoves(9)
 
 	checkMoves(t, m, []Move{
@@ -322,10 +370,10 @@ func TestBoardQueenMoves(t *testing.T) {
 
 func TestBoardKingMoves(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[35] = &Piece{
+	b.setPiece(35, &Piece{
 		kind:  King,
 		color: White,
-	}
+	})
 	m := b.getKingMoves(35)
 
 	checkMoves(t, m, []Move{
@@ -342,14 +390,14 @@ func TestBoardKingMoves(t *testing.T) {
 
 func TestBoardPawnMoves(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[27] = &Piece{
+	b.setPiece(27, &Piece{
 		kind:  Pawn,
 		color: Black,
-	}
-	b.squares[36] = &Piece{
+	})
+	b.setPiece(36, &Piece{
 		kind:  Pawn,
 		color: White,
-	}
+	})
 	m := b.getPawnMoves(36)
 
 	checkMoves(t, m, []Move{
@@ -360,14 +408,14 @@ func TestBoardPawnMoves(t *testing.T) {
 
 func TestBoardPawnPromotion(t *testing.T) {
 	b := NewEmptyBoard()
-	b.squares[5] = &Piece{
+	b.setPiece(5, &Piece{
 		kind:  Rook,
 		color: Black,
-	}
-	b.squares[12] = &Piece{
+	})
+	b.setPiece(12, &Piece{
 		kind:  Pawn,
 		color: White,
-	}
+	})
 	m := b.getPawnMoves(12)
 
 	checkMoves(t, m, []Move{
@@ -382,14 +430,14 @@ func TestBoardPawnPromotion(t *testing.T) {
 	}, "bad pawn moves")
 
 	b = NewEmptyBoard()
-	b.squares[63] = &Piece{
+	b.setPiece(63, &Piece{
 		kind:  Rook,
 		color: White,
-	}
-	b.squares[54] = &Piece{
+	})
+	b.setPiece(54, &Piece{
 		kind:  Pawn,
 		color: Black,
-	}
+	})
 	m = b.getPawnMoves(54)
 
 	checkMoves(t, m, []Move{
@@ -467,8 +515,7 @@ func TestBoardPawnEnPassant(t *testing.T) {
 
 	for i, test := range tests {
 		var enPassant *Move
-		b := NewBoardFromDiagram(test.diagram)
-		b.turn = test.turn
+		b := newBoardFromDiagram(t, test.diagram, test.turn)
 
 		_ = b.Move(&test.firstMove)
 		m := b.GetMoves()
@@ -573,27 +620,193 @@ func TestBoardDrawByRepetition(t *testing.T) {
 		t.Fatalf("expected state to be draw by repetition instead of %s",
 			state.String())
 	}
+	if b.State() != state {
+		t.Fatalf("expected the board state to be %s instead of %s",
+			state, b.State())
+	}
+}
+
+func TestBoardDrawByFivefoldRepetition(t *testing.T) {
+	b := NewBoard()
+	moves := []string{"b1c3", "b8c6", "c3b1", "c6b8"}
+
+	// The threefold repetitions are not claimed, the initial position being
+	// the first one repeated five times.
+	for i := 0; i < 16; i++ {
+		move, _ := ParseUCIMove(b, moves[i%4])
+		state := b.Move(move)
+
+		expected := StatePlaying
+		switch {
+		case i == 15:
+			expected = StateDrawByFivefoldRepetition
+		case i >= 7:
+			expected = StateDrawByRepetition
+		}
+
+		if state != expected {
+			t.Fatalf("move %d: expected state to be %s instead of %s",
+				i, expected.String(), state.String())
+		}
+	}
+}
+
+func TestBoardDrawByFiftyMoves(t *testing.T) {
+	tests := []struct {
+		fen   string
+		move  string
+		state State
+	}{
+		{"4k3/8/8/8/8/8/4P3/R3K3 w - - 98 80", "a1a2", StatePlaying},
+		{"4k3/8/8/8/8/8/4P3/R3K3 w - - 99 80", "a1a2",
+			StateDrawByFiftyMoves},
+		// Pawn moves and captures reset the counter.
+		{"4k3/8/8/8/8/8/4P3/R3K3 w - - 99 80", "e2e4", StatePlaying},
+		{"4k3/8/8/8/8/8/4P3/R3K3 w - - 120 80", "a1a2",
+			StateDrawByFiftyMoves},
+		{"4k3/8/8/8/8/8/4P3/R3K3 w - - 149 80", "a1a2",
+			StateDrawBySeventyFiveMoves},
+		// Checkmate takes precedence.
+		{"6k1/5ppp/8/8/8/8/8/R3K3 w - - 149 80", "a1a8", StateWhiteWins},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: invalid fen: %v", i, err)
+		}
+
+		move, err := ParseUCIMove(b, test.move)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		state := b.Move(move)
+		if state != test.state {
+			t.Errorf("test %d: expected state to be %s instead of %s",
+				i, test.state.String(), state.String())
+		}
+		if state.IsClaimable() !=
+			(state == StateDrawByFiftyMoves) {
+			t.Errorf("test %d: wrong claimable state", i)
+		}
+	}
+}
+
+func TestBoardDrawByRepetitionCastlingRights(t *testing.T) {
+	b, _ := NewBoardFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	moves := []string{"e1f1", "e8f8", "f1e1", "f8e8"}
+
+	// Once the kings are back, the position is not the same as the initial
+	// one since castling rights have been lost: the first position repeated
+	// three times is the one with the kings on f1 and f8.
+	for i := 0; i < 10; i++ {
+		move, err := ParseUCIMove(b, moves[i%4])
+		if err != nil {
+			t.Fatalf("move %d: %v", i, err)
+		}
+
+		state := b.Move(move)
+		if i < 9 && state != StatePlaying {
+			t.Fatalf("move %d: expected state to be playing instead of %s",
+				i, state.String())
+		}
+		if i == 9 && state != StateDrawByRepetition {
+			t.Fatalf("expected state to be draw by repetition instead of %s",
+				state.String())
+		}
+	}
+}
+
+func TestBoardCastlingRights(t *testing.T) {
+	tests := []struct {
+		fen    string
+		moves  []string
+		rights uint8
+	}{
+		{StartFEN, nil, CastleWhiteKingSide | CastleWhiteQueenSide |
+			CastleBlackKingSide | CastleBlackQueenSide},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1e2"},
+			CastleBlackKingSide | CastleBlackQueenSide},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"h1h2", "a8a7"},
+			CastleWhiteQueenSide | CastleBlackKingSide},
+		// Taking a rook removes its castling right.
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"a1a8"},
+			CastleWhiteKingSide | CastleBlackKingSide},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", []string{"e1g1"},
+			CastleBlackKingSide | CastleBlackQueenSide},
+		// Rights are not given back by returning to the initial square.
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			[]string{"h1h2", "e8d8", "h2h1", "d8e8"}, CastleWhiteQueenSide},
+	}
+
+	for i, test := range tests {
+		b, _ := NewBoardFromFEN(test.fen)
+
+		for _, str := range test.moves {
+			move, err := ParseUCIMove(b, str)
+			if err != nil {
+				t.Fatalf("test %d: %v", i, err)
+			}
+			b.Move(move)
+		}
+
+		if b.CastlingRights() != test.rights {
+			t.Errorf("test %d: expected rights %04b instead of %04b",
+				i, test.rights, b.CastlingRights())
+		}
+	}
+}
+
+func TestBoardEnPassantSquare(t *testing.T) {
+	b := NewBoard()
+
+	move, _ := ParseUCIMove(b, "e2e4")
+	b.Move(move)
+
+	ep, ok := b.EnPassantSquare()
+	if !ok || ep.String() != "e3" {
+		t.Fatalf("expected en passant square e3 instead of %s", ep.String())
+	}
+
+	move, _ = ParseUCIMove(b, "g8f6")
+	b.Move(move)
+
+	if _, ok := b.EnPassantSquare(); ok {
+		t.Fatalf("en passant square should be cleared")
+	}
+
+	// A pawn pushed two squares earlier cannot be taken anymore.
+	b, _ = NewBoardFromFEN("4k3/8/8/8/3p4/8/4P1P1/4K3 w - - 0 1")
+	for _, str := range []string{"e2e4", "e8d8", "g2g3"} {
+		move, _ = ParseUCIMove(b, str)
+		b.Move(move)
+	}
+
+	if _, err := ParseUCIMove(b, "d4e3"); err == nil {
+		t.Fatalf("d4e3 should not be legal")
+	}
 }
 
 func TestBoardDrawByStalemate(t *testing.T) {
 	b := NewEmptyBoard()
 
-	b.squares[0] = &Piece{
+	b.setPiece(0, &Piece{
 		kind:  King,
 		color: Black,
-	}
-	b.squares[16] = &Piece{
+	})
+	b.setPiece(16, &Piece{
 		kind:  King,
 		color: White,
-	}
-	b.squares[17] = &Piece{
+	})
+	b.setPiece(17, &Piece{
 		kind:  Rook,
 		color: White,
-	}
-	b.squares[18] = &Piece{
+	})
+	b.setPiece(18, &Piece{
 		kind:  Rook,
 		color: White,
-	}
+	})
 
 	state := b.Move(&Move{from: 18, to: 10})
 	if state != StateDrawByStalemate {
@@ -647,6 +860,86 @@ func TestBoardCheckmate(t *testing.T) {
 	}
 }
 
+func TestBoardCheckers(t *testing.T) {
+	tests := []struct {
+		fen      string
+		checkers []Position
+	}{
+		{StartFEN, nil},
+		// Rook on e8.
+		{"k3r3/8/8/8/8/8/8/4K3 w - - 0 1", []Position{4}},
+		// Rook on e8 and knight on d3.
+		{"4r2k/8/8/R7/8/3n4/8/4K3 w - - 0 1", []Position{4, 43}},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		checkers := b.Checkers()
+		if len(checkers) != len(test.checkers) {
+			t.Fatalf("test %d: expected %v instead of %v",
+				i, test.checkers, checkers)
+		}
+		for j := range checkers {
+			if checkers[j] != test.checkers[j] {
+				t.Fatalf("test %d: expected %v instead of %v",
+					i, test.checkers, checkers)
+			}
+		}
+
+		if b.InCheck() != (len(test.checkers) > 0) {
+			t.Fatalf("test %d: bad check", i)
+		}
+	}
+}
+
+func TestBoardLegalMoves(t *testing.T) {
+	tests := []struct {
+		fen   string
+		moves []string
+	}{
+		// Double check, only the king can move.
+		{"4r2k/8/8/R7/8/3n4/8/4K3 w - - 0 1",
+			[]string{"e1d1", "e1d2", "e1f1"}},
+		// Check, the bishop can take or block the rook.
+		{"k3r3/8/8/1B6/8/8/8/4K3 w - - 0 1",
+			[]string{"b5e2", "b5e8", "e1d1", "e1d2", "e1f1", "e1f2"}},
+		// Pinned rook, only moving along the file.
+		{"k3r3/8/8/8/8/8/4R3/3NK3 w - - 0 1",
+			[]string{"d1b2", "d1c3", "d1e3", "d1f2", "e1d2", "e1f1", "e1f2",
+				"e2e3", "e2e4", "e2e5", "e2e6", "e2e7", "e2e8"}},
+		// The king cannot step back along the ray of the rook.
+		{"k7/8/8/8/8/8/4K3/4r3 w - - 0 1",
+			[]string{"e2d2", "e2d3", "e2e1", "e2f2", "e2f3"}},
+		{"k7/8/8/8/4r3/8/4K3/8 w - - 0 1",
+			[]string{"e2d1", "e2d2", "e2d3", "e2f1", "e2f2", "e2f3"}},
+		// Taking en passant would expose the king along the rank.
+		{"8/8/8/KPp4r/8/8/8/7k w - c6 0 2",
+			[]string{"a5a4", "a5a6", "a5b6", "b5b6"}},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		var moves []string
+		for _, move := range b.GetMoves() {
+			moves = append(moves, move.UCI())
+		}
+		sort.Strings(moves)
+
+		if strings.Join(moves, " ") != strings.Join(test.moves, " ") {
+			t.Errorf("test %d: expected %v instead of %v",
+				i, test.moves, moves)
+		}
+	}
+}
+
 func TestBoardCastlingPossible(t *testing.T) {
 	tests := []struct {
 		diagram string
@@ -714,8 +1007,7 @@ func TestBoardCastlingPossible(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		b := NewBoardFromDiagram(test.diagram)
-		b.turn = test.turn
+		b := newBoardFromDiagram(t, test.diagram, test.turn)
 		m := b.GetMoves()
 
 		var castle *Move
@@ -767,7 +1059,7 @@ func TestBoardCastlingImpossible(t *testing.T) {
 			turn: White,
 		},
 		{
-			diagram: "|K| | | | | | | |\n" +
+			diagram: "| |K| | | | | | |\n" +
 				"| | | | | | | | |\n" +
 				"| | | | | | | | |\n" +
 				"| | | | | | | | |\n" +
@@ -780,7 +1072,7 @@ func TestBoardCastlingImpossible(t *testing.T) {
 			turn: White,
 		},
 		{
-			diagram: "|K| | | | | | | |\n" +
+			diagram: "| |K| | | | | | |\n" +
 				"| | | | | | | | |\n" +
 				"| | | | | | | | |\n" +
 				"| | | | | | | | |\n" +
@@ -806,7 +1098,7 @@ func TestBoardCastlingImpossible(t *testing.T) {
 			turn: White,
 		},
 		{
-			diagram: "| | | | | | | | |\n" +
+			diagram: "|K| | | | | | | |\n" +
 				"| | | | | | | | |\n" +
 				"| | | | | | | | |\n" +
 				"| | | | | | | | |\n" +
@@ -821,8 +1113,7 @@ func TestBoardCastlingImpossible(t *testing.T) {
 	}
 
 	for i, test := range tests {
-		b := NewBoardFromDiagram(test.diagram)
-		b.turn = test.turn
+		b := newBoardFromDiagram(t, test.diagram, test.turn)
 		m := b.GetMoves()
 
 		var castle *Move
@@ -862,8 +1153,7 @@ func TestBoardGetMovesBug(t *testing.T) {
 			"| |p| | | | | |B|" +
 			"|p| | | | | | |p|" +
 			"| | | | |k| | |r|"
-	b := NewBoardFromDiagram(s)
-	b.turn = Black
+	b := newBoardFromDiagram(t, s, Black)
 
 	_ = b.GetMoves()
 }
@@ -878,7 +1168,7 @@ func TestBoardPromotionBug(t *testing.T) {
 			"| | | | | | | | |" +
 			"| | | | | | | | |" +
 			"|k| | | | | | | |"
-	b := NewBoardFromDiagram(s)
+	b := newBoardFromDiagram(t, s, White)
 
 	b.GetMoves()
 }
@@ -946,7 +1236,7 @@ func TestBoardBoardHasEnoughMaterial(t *testing.T) {
 				"| |B| |b| |B| |b|" +
 				"|B| |b| |B| |b| |" +
 				"| |b| |B| |b| |B|" +
-				"|b|K|B| |b| |B| |",
+				"|b|k|B| | | | | |",
 			false},
 		{
 			"    | | | | |K| | | |" +
@@ -978,10 +1268,20 @@ func TestBoardBoardHasEnoughMaterial(t *testing.T) {
 				"|p| | | | | | | |" +
 				"|k| | | | | | | |",
 			true},
+		{
+			"    | | | | |K| | | |" +
+				"| | | | | | | | |" +
+				"| | | | | | | | |" +
+				"| | | | | | | | |" +
+				"| | |b| | | | | |" +
+				"| | | | | | | | |" +
+				"|n| | | | | | | |" +
+				"|k| | | | | | | |",
+			true},
 	}
 
 	for i, test := range tests {
-		b := NewBoardFromDiagram(test.diagram)
+		b := newBoardFromDiagram(t, test.diagram, White)
 		res := b.hasEnoughMaterial()
 		if res != test.hasEnoughMaterial {
 			str := ""
@@ -995,3 +1295,140 @@ func TestBoardBoardHasEnoughMaterial(t *testing.T) {
 		}
 	}
 }
+
+func TestBoardMakeUnmakeMove(t *testing.T) {
+	fens := []string{
+		StartFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"4k3/1P4p1/8/3pP3/8/8/1p4P1/4K3 w - d6 0 1",
+	}
+
+	r := rand.New(rand.NewSource(7))
+
+	for _, fen := range fens {
+		b, _ := NewBoardFromFEN(fen)
+
+		var fens []string
+		var keys []uint64
+		var history []int
+
+		for i := 0; i < 40; i++ {
+			moves := b.GetMoves()
+			if len(moves) == 0 {
+				break
+			}
+
+			fens = append(fens, b.FEN())
+			keys = append(keys, b.key)
+			history = append(history, len(b.history))
+
+			move := moves[r.Intn(len(moves))]
+			b.MakeMove(&move)
+		}
+
+		for i := len(fens) - 1; i >= 0; i-- {
+			b.UnmakeMove()
+
+			if b.FEN() != fens[i] {
+				t.Fatalf("expected %s instead of %s", fens[i], b.FEN())
+			}
+			if b.key != keys[i] || len(b.history) != history[i] {
+				t.Fatalf("key or history not restored for %s", fens[i])
+			}
+			c := b.Clone()
+			c.setBitboards()
+			if b.pieces != c.pieces || b.colors != c.colors {
+				t.Fatalf("bitboards not restored for %s", fens[i])
+			}
+		}
+	}
+}
+
+func TestBoardNullMove(t *testing.T) {
+	fen := "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2"
+	b, _ := NewBoardFromFEN(fen)
+	b.GetMoves()
+
+	move := NewMove(13, 29)
+	b.MakeMove(&move)
+	fen = b.FEN()
+	key := b.key
+
+	b.MakeNullMove()
+
+	// The en passant square is lost.
+	expected, _ := NewBoardFromFEN(
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR b KQkq - 1 3")
+	if b.key != expected.key || b.Turn() != Black {
+		t.Fatalf("expected the key of %s", expected.FEN())
+	}
+	if len(b.GetMoves()) != len(expected.GetMoves()) {
+		t.Fatalf("expected the moves of %s", expected.FEN())
+	}
+
+	b.UnmakeNullMove()
+
+	if b.FEN() != fen || b.key != key {
+		t.Fatalf("expected %s instead of %s", fen, b.FEN())
+	}
+	if len(b.GetMoves()) != 31 {
+		t.Fatalf("expected exf6 to be possible again")
+	}
+}
+
+func TestBoardUndo(t *testing.T) {
+	b := NewBoard()
+	start := b.FEN()
+
+	if err := b.Undo(); err == nil {
+		t.Fatalf("no move to take back")
+	}
+
+	var fens []string
+	for _, str := range []string{"e2e4", "d7d5", "e4d5", "d8d5"} {
+		fens = append(fens, b.FEN())
+
+		move, err := ParseUCIMove(b, str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Move(move)
+	}
+	fens = append(fens, b.FEN())
+
+	moves := b.Moves()
+	if len(moves) != 4 || moves[2].UCI() != "e4d5" {
+		t.Fatalf("unexpected moves: %v", moves)
+	}
+
+	for ply, fen := range fens {
+		position, err := b.PositionAt(ply)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if position.FEN() != fen {
+			t.Fatalf("ply %d: expected %s instead of %s",
+				ply, fen, position.FEN())
+		}
+	}
+
+	if _, err := b.PositionAt(5); err == nil {
+		t.Fatalf("no position at ply 5")
+	}
+	if b.FEN() != fens[4] {
+		t.Fatalf("the board should not change")
+	}
+
+	for i := 3; i >= 0; i-- {
+		if err := b.Undo(); err != nil {
+			t.Fatal(err)
+		}
+		if b.FEN() != fens[i] {
+			t.Fatalf("expected %s instead of %s", fens[i], b.FEN())
+		}
+	}
+
+	if b.FEN() != start || b.hash() != NewBoard().hash() {
+		t.Fatalf("expected the initial position")
+	}
+}
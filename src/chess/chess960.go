@@ -0,0 +1,78 @@
+package chess
+
+import "fmt"
+
+// Number of the standard starting position among the Chess960 ones.
+const StandardChess960Position = 518
+
+var standardBackRank = [8]PieceType{
+	Rook, Knight, Bishop, Queen, King, Bishop, Knight, Rook,
+}
+
+// Squares of the knights among the five squares left once the bishops and
+// the queen are placed, by Chess960 position number.
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// chess960BackRank returns the back rank of the Chess960 position n, from 0
+// to 959, numbered as in https://en.wikipedia.org/wiki/Fischer_random_chess_numbering_scheme
+func chess960BackRank(n int) [8]PieceType {
+	var rank [8]PieceType
+
+	// Bishops on squares of different colors.
+	rank[2*(n%4)+1] = Bishop
+	n /= 4
+	rank[2*(n%4)] = Bishop
+	n /= 4
+
+	// placeNth puts the piece on the nth empty square.
+	placeNth := func(nth int, kind PieceType) {
+		for i := range rank {
+			if rank[i] != Empty {
+				continue
+			}
+
+			if nth == 0 {
+				rank[i] = kind
+				return
+			}
+			nth--
+		}
+	}
+
+	placeNth(n%6, Queen)
+	n /= 6
+
+	// The second knight is placed once the first one is, hence the - 1.
+	knights := chess960Knights[n]
+	placeNth(knights[0], Knight)
+	placeNth(knights[1]-1, Knight)
+
+	// The king stands between the rooks.
+	placeNth(0, Rook)
+	placeNth(0, King)
+	placeNth(0, Rook)
+
+	return rank
+}
+
+// NewChess960Board returns a board set up with the Chess960 position n, from
+// 0 to 959, castling being encoded as the king taking its own rook.
+func NewChess960Board(n int) (*Board, error) {
+	if n < 0 || n >= 960 {
+		return nil, fmt.Errorf("invalid chess960 position %d", n)
+	}
+
+	b := NewEmptyBoard()
+	b.chess960 = true
+
+	b.initPieces(chess960BackRank(n))
+	b.setBitboards()
+	b.setInitialCastlingRights()
+	b.key = b.computeKey()
+	b.history[b.hash()] = 1
+
+	return b, nil
+}
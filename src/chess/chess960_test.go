@@ -0,0 +1,190 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestChess960BackRank(t *testing.T) {
+	if chess960BackRank(StandardChess960Position) != standardBackRank {
+		t.Fatalf("position %d should be the standard one",
+			StandardChess960Position)
+	}
+
+	expected := [8]PieceType{
+		Bishop, Bishop, Queen, Knight, Knight, Rook, King, Rook,
+	}
+	if chess960BackRank(0) != expected {
+		t.Fatalf("expected BBQNNRKR instead of %v", chess960BackRank(0))
+	}
+
+	seen := make(map[[8]PieceType]bool)
+
+	for n := 0; n < 960; n++ {
+		rank := chess960BackRank(n)
+		seen[rank] = true
+
+		var bishops []int
+		var rooksBeforeKing int
+		king := false
+
+		for col, kind := range rank {
+			switch kind {
+			case Bishop:
+				bishops = append(bishops, col)
+			case King:
+				king = true
+			case Rook:
+				if !king {
+					rooksBeforeKing++
+				}
+			}
+		}
+
+		if len(bishops) != 2 || (bishops[0]+bishops[1])%2 == 0 {
+			t.Fatalf("%d: bishops on the same color: %v", n, rank)
+		}
+		if !king || rooksBeforeKing != 1 {
+			t.Fatalf("%d: king not between the rooks: %v", n, rank)
+		}
+	}
+
+	if len(seen) != 960 {
+		t.Fatalf("expected 960 positions instead of %d", len(seen))
+	}
+}
+
+func TestChess960Board(t *testing.T) {
+	if _, err := NewChess960Board(960); err == nil {
+		t.Fatalf("position 960 should not exist")
+	}
+
+	b, err := NewChess960Board(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fen := "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"
+	if b.FEN() != fen {
+		t.Fatalf("expected %s instead of %s", fen, b.FEN())
+	}
+	if len(b.GetMoves()) != 20 {
+		t.Fatalf("expected 20 moves instead of %d", len(b.GetMoves()))
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	tests := []struct {
+		fen   string
+		move  string
+		san   string
+		after string
+	}{
+		// The king stays on c1, the rook goes from b1 to d1.
+		{"4k3/8/8/8/8/8/8/1RK5 w Q - 0 1", "c1b1", "O-O-O",
+			"4k3/8/8/8/8/8/8/2KR4 b - - 1 1"},
+		// The king goes from b1 to g1, over the rook that stays on f1.
+		{"4k3/8/8/8/8/8/8/1K3R2 w K - 0 1", "b1f1", "O-O",
+			"4k3/8/8/8/8/8/8/5RK1 b - - 1 1"},
+		// Inner rook, the king and the rook swap their squares.
+		{"4k3/8/8/8/8/8/8/R1R2K2 w C - 0 1", "f1c1", "O-O-O",
+			"4k3/8/8/8/8/8/8/R1KR4 b - - 1 1"},
+		{"1r2k1r1/8/8/8/8/8/8/4K3 b kq - 0 1", "e8g8", "O-O",
+			"1r3rk1/8/8/8/8/8/8/4K3 w - - 1 2"},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		if !b.chess960 {
+			t.Fatalf("test %d: expected a chess960 board", i)
+		}
+		if b.FEN() != test.fen {
+			t.Fatalf("test %d: expected %s instead of %s",
+				i, test.fen, b.FEN())
+		}
+
+		move, err := ParseUCIMove(b, test.move)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		san, err := ParseSAN(b, test.san)
+		if err != nil || !san.Equals(move) {
+			t.Fatalf("test %d: %s is not %s", i, test.san, test.move)
+		}
+
+		key := b.hash()
+		b.Move(move)
+
+		if b.FEN() != test.after {
+			t.Fatalf("test %d: expected %s instead of %s",
+				i, test.after, b.FEN())
+		}
+		if b.hash() != b.computeKey() {
+			t.Fatalf("test %d: bad key after castling", i)
+		}
+
+		b.UnmakeMove()
+
+		if b.FEN() != test.fen || b.hash() != key {
+			t.Fatalf("test %d: expected %s instead of %s after unmake",
+				i, test.fen, b.FEN())
+		}
+	}
+}
+
+func TestChess960ShredderFEN(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected string
+	}{
+		{"4k3/8/8/8/8/8/8/1RK5 w B - 0 1", "4k3/8/8/8/8/8/8/1RK5 w Q - 0 1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w HAha - 0 1",
+			"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		if b.FEN() != test.expected {
+			t.Errorf("test %d: expected %s instead of %s",
+				i, test.expected, b.FEN())
+		}
+	}
+
+	// Standard squares, not a Chess960 board.
+	b, _ := NewBoardFromFEN(tests[1].fen)
+	if b.chess960 {
+		t.Fatalf("expected a standard board")
+	}
+}
+
+func TestChess960CastlingImpossible(t *testing.T) {
+	fens := []string{
+		// Once the b1 rook is gone, the king is attacked by the a1 rook.
+		"4k3/8/8/8/8/8/8/rRK5 w B - 0 1",
+		// The rook cannot go to d1.
+		"4k3/8/8/8/8/8/8/1RKN4 w B - 0 1",
+		// The king cannot go through f1.
+		"4kr2/8/8/8/8/8/8/1K3R2 w F - 0 1",
+	}
+
+	for i, fen := range fens {
+		b, err := NewBoardFromFEN(fen)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		for _, move := range b.GetMoves() {
+			if _, ok := b.getCastling(&move); ok {
+				t.Errorf("test %d: castling %s should be impossible",
+					i, move.UCI())
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package chess
+
+// Color is the color of a side, White or Black.
+type Color bool
+
+const (
+	White Color = true
+	Black Color = false
+)
+
+// String returns "white" or "black".
+func (c Color) String() string {
+	if c == White {
+		return "white"
+	}
+
+	return "black"
+}
+
+func (c *Color) swap() {
+	*c = !*c
+}
+
+// colorIndex returns the index of the color in the bitboards, white first.
+func colorIndex(c Color) int {
+	if c == White {
+		return 0
+	}
+
+	return 1
+}
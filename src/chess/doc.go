@@ -0,0 +1,16 @@
+// Package chess implements the rules of chess: boards, legal move
+// generation, FEN and algebraic notations, and Chess960.
+//
+// Squares are numbered from 0 (a8) to 63 (h1). A game is played with
+// Board.Move, which returns the state of the game once the move is played:
+//
+//	b := chess.NewBoard()
+//	move, err := chess.ParseSAN(b, "e4")
+//	if err != nil {
+//		return err
+//	}
+//	state := b.Move(move)
+//
+// Moves can be built with NewMove and NewPromotion, and the board inspected
+// with Turn, PieceAt, GetMoves, FEN...
+package chess
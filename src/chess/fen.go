@@ -0,0 +1,328 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// StartFEN is the standard starting position in FEN.
+const StartFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// Castling rights, in the order of their bits. The king and rook squares are
+// the ones of a standard board.
+var fenCastling = []struct {
+	char     string
+	right    uint8
+	king     Position
+	rook     Position
+	color    Color
+	kingSide bool
+}{
+	{"K", CastleWhiteKingSide, 60, 63, White, true},
+	{"Q", CastleWhiteQueenSide, 60, 56, White, false},
+	{"k", CastleBlackKingSide, 4, 7, Black, true},
+	{"q", CastleBlackQueenSide, 4, 0, Black, false},
+}
+
+func fenPieceChar(p *Piece) string {
+	c := pieceChars[p.kind]
+
+	// Unlike diagrams, FEN uses uppercase letters for white pieces.
+	if p.color == White {
+		return strings.ToUpper(c)
+	}
+
+	return c
+}
+
+func fenCharToPiece(r rune) (*Piece, bool) {
+	color := Black
+	if unicode.IsUpper(r) {
+		color = White
+	}
+
+	c := string(unicode.ToLower(r))
+	for kind, char := range pieceChars {
+		if char == c {
+			return &Piece{kind: kind, color: color}, true
+		}
+	}
+
+	return nil, false
+}
+
+// NewBoardFromFEN returns the board described by a FEN, the move counters
+// being optional. It returns an error if the FEN is malformed or the position
+// is not valid (see Board.Validate).
+func NewBoardFromFEN(fen string) (*Board, error) {
+	b := NewEmptyBoard()
+
+	fields := strings.Fields(fen)
+	if len(fields) != 4 && len(fields) != 6 {
+		return nil, fmt.Errorf("invalid fen %q: expected 6 fields "+
+			"instead of %d", fen, len(fields))
+	}
+
+	// Pieces
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return nil, fmt.Errorf("invalid fen %q: expected 8 ranks "+
+			"instead of %d", fen, len(ranks))
+	}
+
+	for row, rank := range ranks {
+		col := 0
+
+		for _, r := range rank {
+			if r >= '1' && r <= '8' {
+				col += int(r - '0')
+				continue
+			}
+
+			piece, ok := fenCharToPiece(r)
+			if !ok {
+				return nil, fmt.Errorf("invalid fen %q: "+
+					"unknown piece %q", fen, r)
+			}
+
+			if col >= 8 {
+				return nil, fmt.Errorf("invalid fen %q: rank %d "+
+					"has more than 8 squares", fen, 8-row)
+			}
+
+			b.squares[row*8+col] = piece
+			col++
+		}
+
+		if col != 8 {
+			return nil, fmt.Errorf("invalid fen %q: rank %d "+
+				"does not have 8 squares", fen, 8-row)
+		}
+	}
+
+	// Side to move
+	switch fields[1] {
+	case "w":
+		b.turn = White
+	case "b":
+		b.turn = Black
+	default:
+		return nil, fmt.Errorf("invalid fen %q: unknown side to move %q",
+			fen, fields[1])
+	}
+
+	// Castling rights
+	err := b.parseFENCastling(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid fen %q: %v", fen, err)
+	}
+
+	// En passant
+	if fields[3] != "-" {
+		ep, err := StringToPosition(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid fen %q: %v", fen, err)
+		}
+
+		pawnPos := ep - 8
+		pawnColor := White
+		if b.turn == White {
+			pawnPos = ep + 8
+			pawnColor = Black
+		}
+
+		if (b.turn == White && ep.getRow() != 2) ||
+			(b.turn == Black && ep.getRow() != 5) {
+			return nil, fmt.Errorf("invalid fen %q: bad en passant "+
+				"square %s", fen, fields[3])
+		}
+
+		pawn := b.squares[pawnPos]
+		if pawn == nil || pawn.kind != Pawn || pawn.color != pawnColor {
+			return nil, fmt.Errorf("invalid fen %q: no pawn to take "+
+				"en passant on %s", fen, fields[3])
+		}
+
+		b.enPassant = ep
+	}
+
+	// Move counters
+	fullMoves := 1
+
+	if len(fields) == 6 {
+		var err error
+
+		b.halfMoves, err = strconv.Atoi(fields[4])
+		if err != nil || b.halfMoves < 0 {
+			return nil, fmt.Errorf("invalid fen %q: bad halfmove "+
+				"clock %q", fen, fields[4])
+		}
+
+		fullMoves, err = strconv.Atoi(fields[5])
+		if err != nil || fullMoves < 1 {
+			return nil, fmt.Errorf("invalid fen %q: bad fullmove "+
+				"number %q", fen, fields[5])
+		}
+	}
+
+	b.nbMoves = (fullMoves - 1) * 2
+	if b.turn == Black {
+		b.nbMoves++
+	}
+
+	b.key = b.computeKey()
+	b.history[b.hash()] = 1
+
+	if err := b.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid fen %q: %v", fen, err)
+	}
+
+	return b, nil
+}
+
+// parseFENCastling parses the castling rights, either "KQkq" for the
+// outermost rooks (X-FEN) or the files of the rooks (Shredder-FEN), and sets
+// the board as a Chess960 one if the king or the rooks are not on their
+// standard squares.
+func (b *Board) parseFENCastling(field string) error {
+	b.setBitboards()
+
+	if field == "-" {
+		return nil
+	}
+
+	for _, r := range field {
+		color := White
+		if unicode.IsLower(r) {
+			color = Black
+		}
+
+		king := b.backRankKing(color)
+		if king == nil {
+			return fmt.Errorf("castling right %q without king on its "+
+				"back rank", r)
+		}
+		kingPos := b.getKingPosition(color)
+
+		var rook Position
+		var kingSide bool
+		var ok bool
+
+		switch unicode.ToUpper(r) {
+		case 'K', 'Q':
+			kingSide = unicode.ToUpper(r) == 'K'
+			rook, ok = b.outermostRook(color, kingSide)
+
+		case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H':
+			col := int(unicode.ToUpper(r) - 'A')
+			rook = Position(backRank(color)*8 + col)
+			kingSide = col > kingPos.getCol()
+
+			p := b.squares[rook]
+			ok = p != nil && p.kind == Rook && p.color == color &&
+				col != kingPos.getCol()
+
+		default:
+			return fmt.Errorf("unknown castling right %q", r)
+		}
+
+		if !ok {
+			return fmt.Errorf("castling right %q without king and rook "+
+				"on their initial squares", r)
+		}
+
+		for i, c := range fenCastling {
+			if c.color != color || c.kingSide != kingSide {
+				continue
+			}
+
+			b.castling |= c.right
+			b.castlingRooks[i] = rook
+
+			if kingPos != c.king || rook != c.rook {
+				b.chess960 = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// FEN returns the position of the board in FEN, the castling rights being
+// given in X-FEN for Chess960 boards.
+func (b *Board) FEN() string {
+	var buf []string
+
+	for row := 0; row < 8; row++ {
+		rank := ""
+		empty := 0
+
+		for col := 0; col < 8; col++ {
+			piece := b.squares[row*8+col]
+			if piece == nil {
+				empty++
+				continue
+			}
+
+			if empty > 0 {
+				rank += strconv.Itoa(empty)
+				empty = 0
+			}
+			rank += fenPieceChar(piece)
+		}
+
+		if empty > 0 {
+			rank += strconv.Itoa(empty)
+		}
+
+		buf = append(buf, rank)
+	}
+
+	turn := "w"
+	if b.turn == Black {
+		turn = "b"
+	}
+
+	castling := b.fenCastling()
+	if castling == "" {
+		castling = "-"
+	}
+
+	ep := "-"
+	if pos, ok := b.EnPassantSquare(); ok {
+		ep = pos.String()
+	}
+
+	return fmt.Sprintf("%s %s %s %s %d %d", strings.Join(buf, "/"),
+		turn, castling, ep, b.halfMoves, b.nbMoves/2+1)
+}
+
+// fenCastling returns the castling rights in X-FEN: "KQkq" for the outermost
+// rooks, the file of the rook for the other ones.
+func (b *Board) fenCastling() string {
+	castling := ""
+	rights := b.CastlingRights()
+
+	for i, c := range fenCastling {
+		if rights&c.right == 0 {
+			continue
+		}
+
+		rook := b.castlingRooks[i]
+		if outermost, ok := b.outermostRook(c.color, c.kingSide); ok &&
+			outermost == rook {
+			castling += c.char
+			continue
+		}
+
+		file := rook.String()[0:1]
+		if c.color == White {
+			file = strings.ToUpper(file)
+		}
+		castling += file
+	}
+
+	return castling
+}
@@ -0,0 +1,112 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestFENRoundTrip(t *testing.T) {
+	tests := []string{
+		StartFEN,
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/8/8/8/8/8/8/R3K2R b Kq - 17 42",
+		"4k3/8/8/8/8/8/8/4K2R w K - 99 120",
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test)
+		if err != nil {
+			t.Fatalf("test %d: cannot parse fen: %v", i, err)
+		}
+
+		if b.FEN() != test {
+			t.Errorf("test %d: expected %s instead of %s",
+				i, test, b.FEN())
+		}
+	}
+}
+
+func TestFENStartPosition(t *testing.T) {
+	b, err := NewBoardFromFEN(StartFEN)
+	if err != nil {
+		t.Fatalf("cannot parse fen: %v", err)
+	}
+
+	if b.getDump() != NewBoard().getDump() {
+		t.Fatalf("expected %s instead of %s",
+			NewBoard().getDump(), b.getDump())
+	}
+
+	if NewBoard().FEN() != StartFEN {
+		t.Fatalf("expected %s instead of %s", StartFEN, NewBoard().FEN())
+	}
+}
+
+func TestFENAfterMoves(t *testing.T) {
+	b := NewBoard()
+	_ = b.Move(&Move{from: 52, to: 36})
+	_ = b.Move(&Move{from: 10, to: 26})
+	_ = b.Move(&Move{from: 62, to: 45})
+
+	expected := "rnbqkbnr/pp1ppppp/8/2p5/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2"
+	if b.FEN() != expected {
+		t.Fatalf("expected %s instead of %s", expected, b.FEN())
+	}
+
+	_ = b.Move(&Move{from: 1, to: 18})
+	_ = b.Move(&Move{from: 61, to: 52})
+	_ = b.Move(&Move{from: 18, to: 35})
+	_ = b.Move(&Move{from: 60, to: 62})
+
+	expected = "r1bqkbnr/pp1ppppp/8/2p5/3nP3/5N2/PPPPBPPP/RNBQ1RK1 b kq - 5 4"
+	if b.FEN() != expected {
+		t.Fatalf("expected %s instead of %s", expected, b.FEN())
+	}
+}
+
+func TestFENEnPassant(t *testing.T) {
+	b, err := NewBoardFromFEN(
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3")
+	if err != nil {
+		t.Fatalf("cannot parse fen: %v", err)
+	}
+
+	found := false
+	for _, move := range b.GetMoves() {
+		if move.from == 28 && move.to == 21 {
+			found = true
+		}
+		if move.from == 28 && move.to == 19 {
+			t.Fatalf("en passant on d6 should not be allowed")
+		}
+	}
+
+	if !found {
+		t.Fatalf("cannot find en passant move on f6")
+	}
+}
+
+func TestFENInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNRR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/9/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNX w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN1 w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkx - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e3 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 0",
+	}
+
+	for i, test := range tests {
+		_, err := NewBoardFromFEN(test)
+		if err == nil {
+			t.Errorf("test %d: expected an error for %q", i, test)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package chess
+
+// Move is a move from a square to another, with the piece type a pawn is
+// promoted to.
+type Move struct {
+	from      Position
+	to        Position
+	promoteTo PieceType
+}
+
+// Moves is a list of moves.
+type Moves []Move
+
+// NewMove returns a move which is not a promotion. It is not checked to be
+// legal.
+func NewMove(from Position, to Position) Move {
+	return Move{
+		from: from,
+		to:   to,
+	}
+}
+
+// NewPromotion returns a pawn move promoted to the given piece type. It is
+// not checked to be legal.
+func NewPromotion(from Position, to Position, promoteTo PieceType) Move {
+	return Move{
+		from:      from,
+		to:        to,
+		promoteTo: promoteTo,
+	}
+}
+
+// From returns the square the piece moves from.
+func (m *Move) From() Position {
+	return m.from
+}
+
+// To returns the square the piece moves to, the square of its own rook when
+// a king castles on a Chess960 board.
+func (m *Move) To() Position {
+	return m.to
+}
+
+// PromoteTo returns the piece type a pawn is promoted to, Empty if the move
+// is not a promotion.
+func (m *Move) PromoteTo() PieceType {
+	return m.promoteTo
+}
+
+// Append adds a move which is not a promotion to the list.
+func (m *Moves) Append(board *Board, from Position, to Position) {
+	move := Move{
+		from: from,
+		to:   to,
+	}
+
+	*m = append(*m, move)
+}
+
+// AppendPromotion adds a pawn move promoted to the given piece type to the
+// list.
+func (m *Moves) AppendPromotion(board *Board,
+	from Position, to Position, promotion PieceType) {
+	move := Move{
+		from:      from,
+		to:        to,
+		promoteTo: promotion,
+	}
+
+	*m = append(*m, move)
+}
+
+func (m *Moves) appendPawnMove(b *Board, from Position, to Position) {
+	if to.isTopBorder() || to.isBottomBorder() {
+		m.AppendPromotion(b, from, to, Queen)
+		m.AppendPromotion(b, from, to, Rook)
+		m.AppendPromotion(b, from, to, Knight)
+		m.AppendPromotion(b, from, to, Bishop)
+	} else {
+		m.Append(b, from, to)
+	}
+}
+
+// String returns the move in UCI notation.
+func (m *Move) String() string {
+	return m.UCI()
+}
+
+// Equals returns true if both moves have the same squares and promotion.
+func (m *Move) Equals(m2 *Move) bool {
+	return m.from == m2.from &&
+		m.to == m2.to &&
+		m.promoteTo == m2.promoteTo
+}
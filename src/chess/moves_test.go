@@ -1,4 +1,4 @@
-package geneticchess
+package chess
 
 import (
 	"testing"
@@ -0,0 +1,251 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UCI returns the move in long algebraic notation as used by the UCI
+// protocol (e.g. "e2e4" or "e7e8q").
+func (m *Move) UCI() string {
+	return m.from.String() + m.to.String() + m.promoteTo.String()
+}
+
+// ParseUCIMove parses a move in long algebraic notation. It returns an error
+// if the notation is invalid or the move is not legal on the board.
+func ParseUCIMove(b *Board, str string) (*Move, error) {
+	if len(str) != 4 && len(str) != 5 {
+		return nil, fmt.Errorf("invalid move: %s", str)
+	}
+
+	from, err := StringToPosition(str[0:2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid move %s: %v", str, err)
+	}
+
+	to, err := StringToPosition(str[2:4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid move %s: %v", str, err)
+	}
+
+	move := &Move{from: from, to: to}
+
+	if len(str) == 5 {
+		move.promoteTo = StringToPieceType(strings.ToLower(str[4:]))
+		if move.promoteTo == Empty || move.promoteTo == King ||
+			move.promoteTo == Pawn {
+			return nil, fmt.Errorf("invalid promotion: %s", str[4:])
+		}
+	}
+
+	for _, m := range b.GetMoves() {
+		if m.Equals(move) {
+			return move, nil
+		}
+	}
+
+	return nil, fmt.Errorf("move %s not allowed", str)
+}
+
+// IsQuiet returns true if the move is neither a capture nor a promotion.
+func (m *Move) IsQuiet(b *Board) bool {
+	return m.promoteTo == Empty && !m.IsCapture(b)
+}
+
+// IsCastling returns true if the move is castling, on a board where it has
+// not been played yet.
+func (m *Move) IsCastling(b *Board) bool {
+	_, ok := b.getCastling(m)
+	return ok
+}
+
+// Captured returns the type of the piece taken by the move, Empty if the move
+// is not a capture.
+func (m *Move) Captured(b *Board) PieceType {
+	if !m.IsCapture(b) {
+		return Empty
+	}
+
+	// En passant takes a pawn on an empty square.
+	if b.squares[m.to] == nil {
+		return Pawn
+	}
+
+	return b.squares[m.to].kind
+}
+
+// IsCapture returns true if the move takes a piece, en passant included, on
+// a board where it has not been played yet.
+func (m *Move) IsCapture(b *Board) bool {
+	if b.squares[m.to] != nil {
+		// On Chess960 boards, the king castles by taking its own rook.
+		return b.squares[m.to].color != b.squares[m.from].color
+	}
+
+	// En passant
+	piece := b.squares[m.from]
+
+	return piece != nil && piece.kind == Pawn &&
+		m.from.getCol() != m.to.getCol()
+}
+
+// san returns the move in standard algebraic notation, without the check and
+// checkmate suffixes.
+func (m *Move) san(b *Board, moves Moves) string {
+	piece := b.squares[m.from]
+
+	if castle, ok := b.getCastling(m); ok {
+		if castle.kingTo.getCol() == 6 {
+			return "O-O"
+		}
+		return "O-O-O"
+	}
+
+	buf := ""
+
+	if piece.kind == Pawn {
+		if m.IsCapture(b) {
+			buf += m.from.String()[0:1] + "x"
+		}
+		buf += m.to.String()
+
+		if m.promoteTo != Empty {
+			buf += "=" + strings.ToUpper(m.promoteTo.String())
+		}
+
+		return buf
+	}
+
+	buf += strings.ToUpper(piece.kind.String())
+
+	// Disambiguation
+	ambiguous := false
+	sameCol := false
+	sameRow := false
+
+	for _, other := range moves {
+		p := b.squares[other.from]
+		if other.from == m.from || other.to != m.to || p.kind != piece.kind {
+			continue
+		}
+
+		ambiguous = true
+		if other.from.getCol() == m.from.getCol() {
+			sameCol = true
+		}
+		if other.from.getRow() == m.from.getRow() {
+			sameRow = true
+		}
+	}
+
+	if ambiguous {
+		if !sameCol {
+			buf += m.from.String()[0:1]
+		} else if !sameRow {
+			buf += m.from.String()[1:2]
+		} else {
+			buf += m.from.String()
+		}
+	}
+
+	if m.IsCapture(b) {
+		buf += "x"
+	}
+
+	return buf + m.to.String()
+}
+
+// SAN returns the move in standard algebraic notation, with the check and
+// checkmate suffixes, on a board where it has not been played yet.
+func (m *Move) SAN(b *Board) string {
+	buf := m.san(b, b.GetMoves())
+
+	b.moveNoCheck(m)
+	defer b.unmoveNoCheck()
+
+	if b.isCheck() {
+		if len(b.GetMoves()) == 0 {
+			buf += "#"
+		} else {
+			buf += "+"
+		}
+	}
+
+	return buf
+}
+
+func stripSAN(str string) string {
+	str = strings.TrimRight(str, "+#!?")
+	str = strings.Replace(str, "=", "", -1)
+	str = strings.Replace(str, "0", "O", -1)
+
+	return str
+}
+
+// ParseSAN parses a move in standard algebraic notation. It is lenient: the
+// disambiguation only filters the moves, so that a needless one is accepted
+// ("Ngf3"), pawn moves can start with "P" ("Pe4") and promotions can be in
+// lowercase ("e8=q").
+func ParseSAN(b *Board, str string) (*Move, error) {
+	stripped := stripSAN(strings.TrimSpace(str))
+	if stripped == "" {
+		return nil, fmt.Errorf("invalid move: %s", str)
+	}
+
+	moves := b.GetMoves()
+
+	if stripped == "O-O" || stripped == "O-O-O" {
+		for _, move := range moves {
+			if move.IsCastling(b) && move.san(b, moves) == stripped {
+				return &Move{from: move.from, to: move.to}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("move %s not allowed", str)
+	}
+
+	// [piece][disambiguation][x]<destination>[promotion]
+	piece := Pawn
+	if strings.Contains("KQRBNP", stripped[0:1]) {
+		piece = StringToPieceType(strings.ToLower(stripped[0:1]))
+		stripped = stripped[1:]
+	}
+
+	promoteTo := Empty
+	n := len(stripped)
+	if n > 2 && strings.Contains("QRBNqrbn", stripped[n-1:]) {
+		promoteTo = StringToPieceType(strings.ToLower(stripped[n-1:]))
+		stripped = stripped[:n-1]
+	}
+
+	if len(stripped) < 2 {
+		return nil, fmt.Errorf("invalid move: %s", str)
+	}
+
+	to, err := StringToPosition(stripped[len(stripped)-2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid move %s: %v", str, err)
+	}
+
+	from := strings.TrimSuffix(stripped[:len(stripped)-2], "x")
+
+	var found *Move
+	for _, move := range moves {
+		if move.to != to || move.promoteTo != promoteTo ||
+			b.squares[move.from].kind != piece || move.IsCastling(b) ||
+			!move.from.matches(from) {
+			continue
+		}
+
+		if found != nil {
+			return nil, fmt.Errorf("ambiguous move: %s", str)
+		}
+		found = &Move{from: move.from, to: move.to, promoteTo: move.promoteTo}
+	}
+
+	if found == nil {
+		return nil, fmt.Errorf("move %s not allowed", str)
+	}
+
+	return found, nil
+}
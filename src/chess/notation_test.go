@@ -0,0 +1,192 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestNotationUCI(t *testing.T) {
+	tests := []struct {
+		move Move
+		uci  string
+	}{
+		{Move{from: 52, to: 36}, "e2e4"},
+		{Move{from: 62, to: 45}, "g1f3"},
+		{Move{from: 60, to: 62}, "e1g1"},
+		{Move{from: 12, to: 4, promoteTo: Queen}, "e7e8q"},
+		{Move{from: 49, to: 56, promoteTo: Knight}, "b2a1n"},
+	}
+
+	for _, test := range tests {
+		if test.move.UCI() != test.uci {
+			t.Errorf("expected %s instead of %s", test.uci, test.move.UCI())
+		}
+		if test.move.String() != test.uci {
+			t.Errorf("expected %s instead of %s",
+				test.uci, test.move.String())
+		}
+	}
+}
+
+func TestNotationParseUCIMove(t *testing.T) {
+	b := NewBoard()
+
+	move, err := ParseUCIMove(b, "e2e4")
+	if err != nil {
+		t.Fatalf("cannot parse move: %v", err)
+	}
+	if !move.Equals(&Move{from: 52, to: 36}) {
+		t.Fatalf("expected e2e4 instead of %s", move)
+	}
+
+	for _, str := range []string{"", "e2", "e2e5", "e7e5", "i2i4", "e2e4k"} {
+		_, err = ParseUCIMove(b, str)
+		if err == nil {
+			t.Errorf("expected an error for %q", str)
+		}
+	}
+
+	b, _ = NewBoardFromFEN("8/4P1k1/8/8/8/8/8/4K3 w - - 0 1")
+	move, err = ParseUCIMove(b, "e7e8n")
+	if err != nil {
+		t.Fatalf("cannot parse move: %v", err)
+	}
+	if !move.Equals(&Move{from: 12, to: 4, promoteTo: Knight}) {
+		t.Fatalf("expected e7e8n instead of %s", move)
+	}
+}
+
+func TestNotationSAN(t *testing.T) {
+	tests := []struct {
+		fen  string
+		move Move
+		san  string
+	}{
+		{StartFEN, Move{from: 52, to: 36}, "e4"},
+		{StartFEN, Move{from: 62, to: 45}, "Nf3"},
+		// Captures and en passant
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			Move{from: 36, to: 27}, "exd5"},
+		{"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+			Move{from: 28, to: 21}, "exf6"},
+		// Castling
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			Move{from: 60, to: 62}, "O-O"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1",
+			Move{from: 4, to: 2}, "O-O-O"},
+		// Disambiguation
+		{"4k3/8/8/8/8/8/4K3/R6R w - - 0 1",
+			Move{from: 56, to: 59}, "Rad1"},
+		{"4k3/8/8/R7/8/8/8/R3K3 w - - 0 1",
+			Move{from: 24, to: 40}, "R5a3"},
+		{"4k3/8/8/8/8/2N1N3/8/6K1 w - - 0 1",
+			Move{from: 44, to: 27}, "Ned5"},
+		{"4k3/4N3/8/8/8/2N1N3/8/6K1 w - - 0 1",
+			Move{from: 44, to: 27}, "Ne3d5"},
+		// Promotions, checks and checkmates
+		{"8/4P1k1/8/8/8/8/8/4K3 w - - 0 1",
+			Move{from: 12, to: 4, promoteTo: Queen}, "e8=Q"},
+		{"8/4P3/8/8/8/8/7k/4K3 w - - 0 1",
+			Move{from: 12, to: 4, promoteTo: Knight}, "e8=N"},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1",
+			Move{from: 56, to: 0}, "Ra8+"},
+		{"6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1",
+			Move{from: 56, to: 0}, "Ra8#"},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: cannot parse fen: %v", i, err)
+		}
+
+		san := test.move.SAN(b)
+		if san != test.san {
+			t.Errorf("test %d: expected %s instead of %s", i, test.san, san)
+		}
+
+		move, err := ParseSAN(b, test.san)
+		if err != nil {
+			t.Errorf("test %d: cannot parse %s: %v", i, test.san, err)
+			continue
+		}
+		if !move.Equals(&test.move) {
+			t.Errorf("test %d: expected %s instead of %s",
+				i, test.move.String(), move.String())
+		}
+	}
+}
+
+func TestNotationParseSAN(t *testing.T) {
+	b := NewBoard()
+
+	for _, str := range []string{"", "e5", "Nd2", "O-O", "Ke2", "xx"} {
+		_, err := ParseSAN(b, str)
+		if err == nil {
+			t.Errorf("expected an error for %q", str)
+		}
+	}
+
+	b, _ = NewBoardFromFEN("r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1")
+	for _, str := range []string{"O-O", "0-0", "O-O+", "O-O!?"} {
+		move, err := ParseSAN(b, str)
+		if err != nil {
+			t.Errorf("cannot parse %s: %v", str, err)
+			continue
+		}
+		if !move.Equals(&Move{from: 60, to: 62}) {
+			t.Errorf("expected e1g1 instead of %s", move)
+		}
+	}
+
+	// Lenient notations
+	tests := []struct {
+		fen  string
+		san  string
+		move Move
+	}{
+		{StartFEN, "Pe4", Move{from: 52, to: 36}},
+		{StartFEN, "Ngf3", Move{from: 62, to: 45}},
+		{StartFEN, "Ng1f3", Move{from: 62, to: 45}},
+		{StartFEN, "e2e4", Move{from: 52, to: 36}},
+		{"8/4P1k1/8/8/8/8/8/4K3 w - - 0 1", "e8=q",
+			Move{from: 12, to: 4, promoteTo: Queen}},
+		{"8/4P1k1/8/8/8/8/8/4K3 w - - 0 1", "e8n",
+			Move{from: 12, to: 4, promoteTo: Knight}},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2",
+			"ed5", Move{from: 36, to: 27}},
+	}
+
+	for i, test := range tests {
+		b, _ := NewBoardFromFEN(test.fen)
+
+		move, err := ParseSAN(b, test.san)
+		if err != nil {
+			t.Errorf("test %d: cannot parse %s: %v", i, test.san, err)
+			continue
+		}
+		if !move.Equals(&test.move) {
+			t.Errorf("test %d: expected %s instead of %s",
+				i, test.move.String(), move.String())
+		}
+	}
+
+	// Ambiguous, wrongly disambiguated or missing promotion
+	invalid := []struct {
+		fen string
+		san string
+	}{
+		{"4k3/8/8/8/8/2N1N3/8/6K1 w - - 0 1", "Nd5"},
+		{"4k3/8/8/8/8/2N1N3/8/6K1 w - - 0 1", "Nfd5"},
+		{"8/4P1k1/8/8/8/8/8/4K3 w - - 0 1", "e8"},
+		{"8/4P1k1/8/8/8/8/8/4K3 w - - 0 1", "e8=K"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "Kg1"},
+	}
+
+	for i, test := range invalid {
+		b, _ := NewBoardFromFEN(test.fen)
+
+		if _, err := ParseSAN(b, test.san); err == nil {
+			t.Errorf("test %d: expected an error for %s", i, test.san)
+		}
+	}
+}
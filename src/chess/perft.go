@@ -0,0 +1,91 @@
+package chess
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+// Perft returns the number of leaf nodes of the tree of legal moves of the
+// given depth, to be compared with known counts to check the move generator.
+func Perft(b *Board, depth uint) uint64 {
+	if depth == 0 {
+		return 1
+	}
+
+	moves := b.GetMoves()
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+
+	var nodes uint64
+
+	for i := range moves {
+		b.moveNoCheck(&moves[i])
+		nodes += Perft(b, depth-1)
+		b.unmoveNoCheck()
+	}
+
+	return nodes
+}
+
+// Divide returns the perft count of the given depth below each legal move,
+// indexed by the move in UCI notation.
+func Divide(b *Board, depth uint) map[string]uint64 {
+	res := make(map[string]uint64)
+
+	if depth == 0 {
+		return res
+	}
+
+	for _, move := range b.GetMoves() {
+		b.moveNoCheck(&move)
+		res[move.UCI()] = Perft(b, depth-1)
+		b.unmoveNoCheck()
+	}
+
+	return res
+}
+
+// RunPerft prints the perft count of each depth up to maxDepth, and the
+// divided count of the last one.
+func RunPerft(fen string, maxDepth uint) error {
+	return runPerft(os.Stdout, fen, maxDepth)
+}
+
+func runPerft(out io.Writer, fen string, maxDepth uint) error {
+	b, err := NewBoardFromFEN(fen)
+	if err != nil {
+		return err
+	}
+
+	for depth := uint(1); depth < maxDepth; depth++ {
+		start := time.Now()
+		nodes := Perft(b, depth)
+
+		fmt.Fprintf(out, "perft %d: %d (%v)\n", depth, nodes,
+			time.Now().Sub(start))
+	}
+
+	start := time.Now()
+	divide := Divide(b, maxDepth)
+
+	var moves []string
+	var nodes uint64
+	for move, n := range divide {
+		moves = append(moves, move)
+		nodes += n
+	}
+	sort.Strings(moves)
+
+	for _, move := range moves {
+		fmt.Fprintf(out, "%s: %d\n", move, divide[move])
+	}
+
+	fmt.Fprintf(out, "perft %d: %d (%v)\n", maxDepth, nodes,
+		time.Now().Sub(start))
+
+	return nil
+}
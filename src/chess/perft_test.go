@@ -0,0 +1,137 @@
+package chess
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Known counts from https://www.chessprogramming.org/Perft_Results
+var perftTests = []struct {
+	name   string
+	fen    string
+	counts []uint64
+}{
+	{"start", StartFEN, []uint64{20, 400, 8902, 197281, 4865609}},
+	{"kiwipete",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		[]uint64{48, 2039, 97862, 4085603}},
+	{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		[]uint64{14, 191, 2812, 43238, 674624}},
+	{"position 4",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		[]uint64{6, 264, 9467, 422333}},
+	{"position 4 mirrored",
+		"r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		[]uint64{6, 264, 9467}},
+	{"position 5",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		[]uint64{44, 1486, 62379, 2103487}},
+	{"position 6",
+		"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		[]uint64{46, 2079, 89890, 3894594}},
+	{"chess960 1",
+		"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+		[]uint64{21, 528, 12189, 326672}},
+	{"chess960 2",
+		"2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9",
+		[]uint64{21, 807, 18002, 667366}},
+}
+
+func TestPerft(t *testing.T) {
+	for _, test := range perftTests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("%s: invalid fen: %v", test.name, err)
+		}
+
+		for i, expected := range test.counts {
+			depth := uint(i + 1)
+			if testing.Short() && depth > 2 {
+				break
+			}
+
+			nodes := Perft(b, depth)
+			if nodes != expected {
+				t.Errorf("%s: expected %d nodes at depth %d instead of %d",
+					test.name, expected, depth, nodes)
+				break
+			}
+		}
+	}
+}
+
+func TestPerftChess960Standard(t *testing.T) {
+	// The standard position played as a Chess960 one.
+	b, err := NewChess960Board(StandardChess960Position)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nodes := Perft(b, 3); nodes != 8902 {
+		t.Fatalf("expected 8902 nodes instead of %d", nodes)
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	b := NewBoard()
+
+	divide := Divide(b, 2)
+	if len(divide) != 20 {
+		t.Fatalf("expected 20 moves instead of %d", len(divide))
+	}
+
+	var nodes uint64
+	for _, n := range divide {
+		nodes += n
+	}
+	if nodes != 400 {
+		t.Fatalf("expected 400 nodes instead of %d", nodes)
+	}
+	if divide["e2e4"] != 20 {
+		t.Fatalf("expected 20 nodes after e2e4 instead of %d", divide["e2e4"])
+	}
+}
+
+func TestPerftRun(t *testing.T) {
+	var out bytes.Buffer
+
+	err := runPerft(&out, StartFEN, 2)
+	if err != nil {
+		t.Fatalf("perft failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 22 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "perft 1: 20 ") {
+		t.Fatalf("unexpected first line: %s", lines[0])
+	}
+	if lines[1] != "a2a3: 20" {
+		t.Fatalf("unexpected divide line: %s", lines[1])
+	}
+	if !strings.HasPrefix(lines[21], "perft 2: 400 ") {
+		t.Fatalf("unexpected last line: %s", lines[21])
+	}
+
+	if runPerft(&out, "invalid", 2) == nil {
+		t.Fatalf("expected an error for an invalid fen")
+	}
+}
+
+func TestPerftEnPassantPin(t *testing.T) {
+	// Taking en passant would leave the king in check on the fourth rank.
+	b, err := NewBoardFromFEN("8/8/8/8/k2Pp2Q/8/8/3K4 b - d3 0 1")
+	if err != nil {
+		t.Fatalf("invalid fen: %v", err)
+	}
+
+	divide := Divide(b, 1)
+	if _, ok := divide["e4d3"]; ok {
+		t.Fatalf("e4d3 should not be legal")
+	}
+	if _, ok := divide["e4e3"]; !ok {
+		t.Fatalf("e4e3 should be legal")
+	}
+}
@@ -0,0 +1,133 @@
+package chess
+
+import (
+	"log"
+	"strings"
+)
+
+// PieceType is the kind of a piece, Empty for no piece.
+type PieceType uint8
+
+// Piece is a piece of a given kind and color.
+type Piece struct {
+	kind  PieceType
+	color Color
+}
+
+var pieceChars = map[PieceType]string{
+	King:   "k",
+	Queen:  "q",
+	Rook:   "r",
+	Knight: "n",
+	Bishop: "b",
+	Pawn:   "p",
+}
+
+var fullNames = map[PieceType]string{
+	King:   "King",
+	Queen:  "Queen",
+	Rook:   "Rook",
+	Knight: "Knight",
+	Bishop: "Bishop",
+	Pawn:   "Pawn",
+}
+
+const (
+	Empty  PieceType = 0
+	King   PieceType = 1
+	Queen  PieceType = 2
+	Rook   PieceType = 3
+	Knight PieceType = 4
+	Bishop PieceType = 5
+	Pawn   PieceType = 6
+)
+
+const (
+	CastleWhiteKingSide  uint8 = 1 << 0
+	CastleWhiteQueenSide uint8 = 1 << 1
+	CastleBlackKingSide  uint8 = 1 << 2
+	CastleBlackQueenSide uint8 = 1 << 3
+)
+
+// String returns the lowercase letter of the piece type (e.g. "n"), empty
+// for Empty.
+func (pt PieceType) String() string {
+	res, ok := pieceChars[pt]
+	if ok {
+		return res
+	}
+
+	return ""
+}
+
+// GetName returns the name of the piece type (e.g. "Knight"), empty for
+// Empty.
+func (pt PieceType) GetName() string {
+	res, ok := fullNames[pt]
+	if ok {
+		return res
+	}
+
+	return ""
+}
+
+// NewPiece returns a piece of the given kind and color.
+func NewPiece(kind PieceType, color Color) *Piece {
+	return &Piece{
+		kind:  kind,
+		color: color,
+	}
+}
+
+// Kind returns the type of the piece.
+func (p *Piece) Kind() PieceType {
+	return p.kind
+}
+
+// Color returns the color of the piece.
+func (p *Piece) Color() Color {
+	return p.color
+}
+
+// String returns the letter of the piece, lowercase for white and uppercase
+// for black as in the diagrams of Dump.
+func (p *Piece) String() string {
+	c, ok := pieceChars[p.kind]
+	if !ok {
+		log.Panicf("unknown piece type: %d", p.kind)
+	}
+
+	if p.color == Black {
+		return strings.ToUpper(c)
+	}
+
+	return c
+}
+
+func (p *Piece) clone() *Piece {
+	return &Piece{
+		kind:  p.kind,
+		color: p.color,
+	}
+}
+
+// StringToPieceType returns the piece type of a lowercase letter (e.g. "n"),
+// Empty if the letter is not a piece.
+func StringToPieceType(str string) PieceType {
+	switch str {
+	case "k":
+		return King
+	case "q":
+		return Queen
+	case "r":
+		return Rook
+	case "n":
+		return Knight
+	case "b":
+		return Bishop
+	case "p":
+		return Pawn
+	default:
+		return Empty
+	}
+}
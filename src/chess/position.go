@@ -1,37 +1,11 @@
-package geneticchess
+package chess
 
-type Position uint8
+import (
+	"fmt"
+)
 
-var borders = map[Position]bool{
-	0:  true,
-	1:  true,
-	2:  true,
-	3:  true,
-	4:  true,
-	5:  true,
-	6:  true,
-	8:  true,
-	7:  true,
-	15: true,
-	16: true,
-	23: true,
-	24: true,
-	31: true,
-	32: true,
-	39: true,
-	40: true,
-	47: true,
-	48: true,
-	55: true,
-	56: true,
-	57: true,
-	58: true,
-	59: true,
-	60: true,
-	61: true,
-	62: true,
-	63: true,
-}
+// Position is a square of the board, from 0 (a8) to 63 (h1).
+type Position uint8
 
 const (
 	InitialPositionBlackRookA   Position = 0
@@ -90,3 +64,46 @@ func (p Position) isLeftBorder() bool {
 func (p Position) isRightBorder() bool {
 	return p%8 == 7
 }
+
+// String returns the name of the square (e.g. "e4").
+func (p Position) String() string {
+	return fmt.Sprintf("%c%c", 'a'+p.getCol(), '8'-p.getRow())
+}
+
+// matches returns true if the position is in the files and ranks of the
+// disambiguation of a move (e.g. "g", "1" or "g1").
+func (p Position) matches(disambiguation string) bool {
+	square := p.String()
+
+	for _, c := range disambiguation {
+		switch {
+		case c >= 'a' && c <= 'h':
+			if byte(c) != square[0] {
+				return false
+			}
+		case c >= '1' && c <= '8':
+			if byte(c) != square[1] {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// StringToPosition returns the square of a name such as "e4". It returns an
+// error if the name is not a square.
+func StringToPosition(str string) (Position, error) {
+	if len(str) != 2 ||
+		str[0] < 'a' || str[0] > 'h' ||
+		str[1] < '1' || str[1] > '8' {
+		return 0, fmt.Errorf("invalid square: %s", str)
+	}
+
+	col := int(str[0] - 'a')
+	row := int('8' - str[1])
+
+	return Position(row*8 + col), nil
+}
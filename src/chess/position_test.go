@@ -1,4 +1,4 @@
-package geneticchess
+package chess
 
 import (
 	"testing"
@@ -201,3 +201,39 @@ func TestPositionGetSquaresFromRight(t *testing.T) {
 		t.Fatalf("expected %d got %d", 5, squares)
 	}
 }
+
+func TestPositionString(t *testing.T) {
+	tests := []struct {
+		pos Position
+		str string
+	}{
+		{0, "a8"},
+		{7, "h8"},
+		{36, "e4"},
+		{52, "e2"},
+		{56, "a1"},
+		{63, "h1"},
+	}
+
+	for _, test := range tests {
+		if test.pos.String() != test.str {
+			t.Errorf("expected %s instead of %s",
+				test.str, test.pos.String())
+		}
+
+		pos, err := StringToPosition(test.str)
+		if err != nil {
+			t.Errorf("cannot parse %s: %v", test.str, err)
+		}
+		if pos != test.pos {
+			t.Errorf("expected %d instead of %d", test.pos, pos)
+		}
+	}
+
+	for _, str := range []string{"", "e", "i1", "a9", "e22"} {
+		_, err := StringToPosition(str)
+		if err == nil {
+			t.Errorf("expected an error for %q", str)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package chess
+
+// Values of the pieces used by the static exchange evaluation, in
+// centipawns. The king can only take last.
+var seeValues = [7]int{
+	Empty:  0,
+	King:   10000,
+	Queen:  900,
+	Rook:   500,
+	Knight: 300,
+	Bishop: 300,
+	Pawn:   100,
+}
+
+// Pieces from the least to the most valuable one.
+var seeOrder = []PieceType{Pawn, Knight, Bishop, Rook, Queen, King}
+
+// AttackersTo returns the positions of the pieces of the given color
+// attacking the square, whether it is empty or not.
+func (b *Board) AttackersTo(pos Position, color Color) []Position {
+	var list []Position
+
+	for bb := b.attackers(pos, color, b.occupied()); bb != 0; {
+		list = append(list, bb.pop())
+	}
+
+	return list
+}
+
+// SEE returns the material won by the move, in centipawns, once all the
+// captures on its destination square are played, each side capturing with its
+// least valuable piece and being free to stop capturing. Pins are ignored.
+func (b *Board) SEE(move *Move) int {
+	piece := b.squares[move.from]
+	if piece == nil || move.IsCastling(b) {
+		return 0
+	}
+
+	var gain [32]int
+
+	to := move.to
+	occupied := b.occupied() ^ squareBB(move.from)
+	attacker := piece.kind
+
+	gain[0] = seeValues[move.Captured(b)]
+	if b.squares[to] == nil && gain[0] != 0 {
+		// The pawn taken en passant is not on the destination square.
+		occupied ^= squareBB(Position(move.from.getRow()*8 + to.getCol()))
+	}
+	if move.promoteTo != Empty {
+		gain[0] += seeValues[move.promoteTo] - seeValues[Pawn]
+		attacker = move.promoteTo
+	}
+
+	color := piece.color
+	d := 0
+
+	for d < len(gain)-1 {
+		d++
+		color = !color
+
+		// Gain of the side to capture, if it can.
+		gain[d] = seeValues[attacker] - gain[d-1]
+
+		from, kind, ok := b.leastValuableAttacker(to, color, occupied)
+		if !ok {
+			break
+		}
+
+		occupied ^= squareBB(from)
+		attacker = kind
+	}
+
+	for d--; d > 0; d-- {
+		gain[d-1] = -max(-gain[d-1], gain[d])
+	}
+
+	return gain[0]
+}
+
+// leastValuableAttacker returns the least valuable piece of the given color
+// attacking the square, among the occupied squares. The king cannot take a
+// defended piece.
+func (b *Board) leastValuableAttacker(pos Position, color Color,
+	occupied bitboard) (Position, PieceType, bool) {
+	c := colorIndex(color)
+	attackers := b.attackers(pos, color, occupied) & occupied
+
+	for _, kind := range seeOrder {
+		bb := attackers & b.pieces[c][kind]
+		if bb == 0 {
+			continue
+		}
+
+		if kind == King && b.attackers(pos, !color, occupied)&occupied != 0 {
+			return 0, Empty, false
+		}
+
+		return bb.first(), kind, true
+	}
+
+	return 0, Empty, false
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
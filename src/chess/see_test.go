@@ -0,0 +1,76 @@
+package chess
+
+import "testing"
+
+func TestBoardAttackersTo(t *testing.T) {
+	b, err := NewBoardFromFEN(
+		"rnbqkbnr/pppp1ppp/8/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R b KQkq - 1 2")
+	if err != nil {
+		t.Fatalf("invalid fen: %v", err)
+	}
+
+	// e5 is attacked by the knight on f3 and defended by nobody.
+	attackers := b.AttackersTo(28, White)
+	if len(attackers) != 1 || attackers[0] != 45 {
+		t.Fatalf("expected [45] instead of %v", attackers)
+	}
+	if attackers := b.AttackersTo(28, Black); len(attackers) != 0 {
+		t.Fatalf("expected no black attackers instead of %v", attackers)
+	}
+
+	// f6 is attacked by the queen, the g8 knight and the g7 pawn.
+	attackers = b.AttackersTo(21, Black)
+	if len(attackers) != 3 {
+		t.Fatalf("expected 3 attackers instead of %v", attackers)
+	}
+}
+
+func TestBoardSEE(t *testing.T) {
+	tests := []struct {
+		fen      string
+		move     string
+		expected int
+	}{
+		// Undefended pawn.
+		{"1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		// The knight is lost for a pawn.
+		{"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1",
+			"d3e5", -200},
+		// Rook defended by the king.
+		{"4k3/8/8/8/8/5n2/8/4RK2 b - - 0 1", "f3e1", 200},
+		// Pawn defended by a pawn, taken by a pawn.
+		{"4k3/8/3p4/4p3/3P4/8/8/4K3 w - - 0 1", "d4e5", 0},
+		// Queen moving to a square attacked by a pawn.
+		{"4k3/8/8/3p4/8/8/8/2Q1K3 w - - 0 1", "c1c4", -900},
+		// Quiet move to a safe square.
+		{StartFEN, "e2e4", 0},
+		// The rooks behind the queen take back (x-ray), white losing the
+		// queen for a pawn.
+		{"3rk3/3r4/8/3p4/8/8/3Q4/3RK3 w - - 0 1", "d2d5", 100 - 900},
+		// The king cannot take back a defended piece.
+		{"4k3/3p4/8/8/8/8/3R4/3Q1K2 w - - 0 1", "d2d7", 100},
+		{"4k3/3p4/8/8/8/8/3R4/5K2 w - - 0 1", "d2d7", 100 - 500},
+		// En passant.
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "e5d6", 100},
+		// Promotion.
+		{"4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7a8q", 800},
+		{"r3k3/1P6/8/8/8/8/8/4K3 w - - 0 1", "b7a8q", 500 + 800},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		move, err := ParseUCIMove(b, test.move)
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+
+		if see := b.SEE(move); see != test.expected {
+			t.Errorf("test %d: expected %d instead of %d",
+				i, test.expected, see)
+		}
+	}
+}
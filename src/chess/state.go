@@ -0,0 +1,51 @@
+package chess
+
+import ()
+
+// State is the state of a game, StatePlaying while it is not over.
+type State int8
+
+const (
+	StatePlaying                    State = 0
+	StateWhiteWins                  State = 1
+	StateBlackWins                  State = 2
+	StateDrawByRepetition           State = 3
+	StateDrawByStalemate            State = 4
+	StateDrawByInsufficientMaterial State = 5
+	StateDrawByFiftyMoves           State = 6
+	StateDrawBySeventyFiveMoves     State = 7
+	StateDrawByFivefoldRepetition   State = 8
+)
+
+// String describes the state (e.g. "draw by stalemate").
+func (s State) String() string {
+	switch s {
+	case StatePlaying:
+		return "playing"
+	case StateWhiteWins:
+		return "white wins"
+	case StateBlackWins:
+		return "black wins"
+	case StateDrawByRepetition:
+		return "draw by repetition"
+	case StateDrawByStalemate:
+		return "draw by stalemate"
+	case StateDrawByInsufficientMaterial:
+		return "draw by insufficient material"
+	case StateDrawByFiftyMoves:
+		return "draw by fifty-move rule"
+	case StateDrawBySeventyFiveMoves:
+		return "draw by seventy-five-move rule"
+	case StateDrawByFivefoldRepetition:
+		return "draw by fivefold repetition"
+	default:
+		panic("unknown state")
+	}
+}
+
+// IsClaimable returns true for draws that a player may claim, the game going
+// on otherwise: threefold repetition and the fifty-move rule. All the other
+// draws end the game automatically.
+func (s State) IsClaimable() bool {
+	return s == StateDrawByRepetition || s == StateDrawByFiftyMoves
+}
@@ -0,0 +1,68 @@
+package chess
+
+import "fmt"
+
+// Validate returns an error if the position cannot be reached in a game:
+// each side must have exactly one king, at most 16 pieces and 8 pawns, no
+// pawn on the first or last rank, and the side that just moved must not be
+// in check. The castling rights and the en passant square must match the
+// pieces on the board.
+func (b *Board) Validate() error {
+	for _, color := range []Color{White, Black} {
+		pieces := b.pieces[colorIndex(color)]
+
+		if n := pieces[King].count(); n != 1 {
+			return fmt.Errorf("%s has %d kings instead of 1", color, n)
+		}
+		if n := pieces[Pawn].count(); n > 8 {
+			return fmt.Errorf("%s has %d pawns, more than 8", color, n)
+		}
+		if n := b.colors[colorIndex(color)].count(); n > 16 {
+			return fmt.Errorf("%s has %d pieces, more than 16", color, n)
+		}
+	}
+
+	for pos := Position(0); pos < 64; pos++ {
+		p := b.squares[pos]
+		if p != nil && p.kind == Pawn && (pos.getRow() == 0 || pos.getRow() == 7) {
+			return fmt.Errorf("pawn on %s", pos)
+		}
+	}
+
+	if b.isKingAttacked(!b.turn) {
+		return fmt.Errorf("%s is in check while %s is to move", !b.turn, b.turn)
+	}
+
+	for i, c := range fenCastling {
+		if b.castling&c.right == 0 {
+			continue
+		}
+
+		rook := b.squares[b.castlingRooks[i]]
+		if b.backRankKing(c.color) == nil || rook == nil ||
+			rook.kind != Rook || rook.color != c.color {
+			return fmt.Errorf("castling right %s without king and rook on "+
+				"their initial squares", c.char)
+		}
+	}
+
+	if ep, ok := b.EnPassantSquare(); ok {
+		// The pawn that moved two squares stands in front of the en passant
+		// square, the square it crossed and the one it left being empty.
+		row, pawnPos, fromPos := 2, ep+8, ep-8
+		if b.turn == Black {
+			row, pawnPos, fromPos = 5, ep-8, ep+8
+		}
+		if ep.getRow() != row {
+			return fmt.Errorf("bad en passant square %s", ep)
+		}
+
+		pawn := b.squares[pawnPos]
+		if pawn == nil || pawn.kind != Pawn || pawn.color == b.turn ||
+			b.squares[ep] != nil || b.squares[fromPos] != nil {
+			return fmt.Errorf("no pawn to take en passant on %s", ep)
+		}
+	}
+
+	return nil
+}
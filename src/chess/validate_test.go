@@ -0,0 +1,60 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBoardValidate(t *testing.T) {
+	tests := []struct {
+		fen string
+		err string
+	}{
+		{StartFEN, ""},
+		{"4k3/8/8/8/8/8/8/8 w - - 0 1", "white has 0 kings"},
+		{"4k3/8/8/8/8/8/8/2K1K3 w - - 0 1", "white has 2 kings"},
+		{"4k3/8/8/8/8/8/8/P3K3 w - - 0 1", "pawn on a1"},
+		{"4k3/8/8/8/8/P7/PPPPPPPP/4K3 w - - 0 1", "white has 9 pawns"},
+		{"k7/8/8/8/8/QQQQQQQQ/QQQQQQQQ/4K3 w - - 0 1", "white has 17 pieces"},
+		{"4k3/8/8/8/8/8/8/4K2R w - - 0 1", ""},
+		{"4k3/8/8/8/8/8/8/4R1K1 w - - 0 1", "black is in check"},
+		{"4k3/8/8/8/8/8/8/4R1K1 b - - 0 1", ""},
+	}
+
+	for i, test := range tests {
+		b, err := NewBoardFromFEN(test.fen)
+
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			} else if err := b.Validate(); err != nil {
+				t.Errorf("test %d: unexpected error: %v", i, err)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: expected error %q instead of %v",
+				i, test.err, err)
+		}
+	}
+}
+
+func TestBoardValidateDiagram(t *testing.T) {
+	tests := []struct {
+		diagram string
+		err     string
+	}{
+		{"|K| | | | | | | |", "expected 64 squares"},
+		{strings.Repeat("| | | | | | | | |", 8) + "| |", "more than 64"},
+		{strings.Repeat("| | | | | | | | |", 8), "white has 0 kings"},
+	}
+
+	for i, test := range tests {
+		_, err := NewBoardFromDiagram(test.diagram, White)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("test %d: expected error %q instead of %v",
+				i, test.err, err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package chess
+
+var (
+	zobristPieces    [2][7][64]uint64
+	zobristCastling  [16]uint64
+	zobristEnPassant [8]uint64
+	zobristBlack     uint64
+)
+
+func init() {
+	// Fixed seed so that keys are the same from one run to another.
+	seed := uint64(0x9e3779b97f4a7c15)
+	next := func() uint64 {
+		// xorshift64*
+		seed ^= seed >> 12
+		seed ^= seed << 25
+		seed ^= seed >> 27
+		return seed * 2685821657736338717
+	}
+
+	for c := range zobristPieces {
+		for k := range zobristPieces[c] {
+			for pos := range zobristPieces[c][k] {
+				zobristPieces[c][k][pos] = next()
+			}
+		}
+	}
+
+	for i := range zobristCastling {
+		zobristCastling[i] = next()
+	}
+
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+
+	zobristBlack = next()
+}
+
+func zobristPiece(p *Piece, pos Position) uint64 {
+	c := 0
+	if p.color == Black {
+		c = 1
+	}
+
+	return zobristPieces[c][p.kind][pos]
+}
+
+// zobristEnPassantKey only takes the en passant square into account when a
+// pawn can actually take on it, so that positions with the same possible
+// moves share the same key.
+func (b *Board) zobristEnPassantKey() uint64 {
+	ep, ok := b.EnPassantSquare()
+	if !ok {
+		return 0
+	}
+
+	// Pawns able to take are next to the pawn that moved two squares.
+	pawnPos := int(ep) + 8
+	if b.turn == Black {
+		pawnPos = int(ep) - 8
+	}
+
+	for _, pos := range []int{pawnPos - 1, pawnPos + 1} {
+		if pos/8 != pawnPos/8 {
+			continue
+		}
+
+		p := b.squares[pos]
+		if p != nil && p.kind == Pawn && p.color == b.turn {
+			return zobristEnPassant[ep.getCol()]
+		}
+	}
+
+	return 0
+}
+
+// computeKey computes the Zobrist key of the board from scratch. It is
+// updated incrementally on each move.
+func (b *Board) computeKey() uint64 {
+	var key uint64
+
+	for i, p := range b.squares {
+		if p != nil {
+			key ^= zobristPiece(p, Position(i))
+		}
+	}
+
+	key ^= zobristCastling[b.CastlingRights()]
+	key ^= b.zobristEnPassantKey()
+
+	if b.turn == Black {
+		key ^= zobristBlack
+	}
+
+	return key
+}
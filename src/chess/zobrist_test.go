@@ -0,0 +1,91 @@
+package chess
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestZobristIncrementalKey(t *testing.T) {
+	fens := []string{
+		StartFEN,
+		// Castling on both sides.
+		"r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1",
+		// En passant and promotions.
+		"4k3/1P4p1/8/3pP3/8/8/1p4P1/4K3 w - d6 0 1",
+	}
+
+	r := rand.New(rand.NewSource(42))
+
+	for _, fen := range fens {
+		for game := 0; game < 20; game++ {
+			b, err := NewBoardFromFEN(fen)
+			if err != nil {
+				t.Fatalf("invalid fen %s: %v", fen, err)
+			}
+
+			for i := 0; i < 100; i++ {
+				moves := b.GetMoves()
+				if len(moves) == 0 {
+					break
+				}
+
+				move := moves[r.Intn(len(moves))]
+				state := b.Move(&move)
+
+				if b.key != b.computeKey() {
+					t.Fatalf("wrong key after %s in %s", move.UCI(), b.FEN())
+				}
+				if state != StatePlaying {
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestZobristDifferentKeys(t *testing.T) {
+	tests := []struct {
+		fen1 string
+		fen2 string
+	}{
+		// Side to move.
+		{"4k3/8/8/8/8/8/8/4K3 w - - 0 1", "4k3/8/8/8/8/8/8/4K3 b - - 0 1"},
+		// Castling rights.
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1",
+			"r3k2r/8/8/8/8/8/8/R3K2R w Kkq - 0 1"},
+		// En passant square, a pawn being able to take.
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1",
+			"4k3/8/8/3pP3/8/8/8/4K3 w - - 0 1"},
+	}
+
+	for _, test := range tests {
+		b1, err := NewBoardFromFEN(test.fen1)
+		if err != nil {
+			t.Fatalf("invalid fen %s: %v", test.fen1, err)
+		}
+		b2, err := NewBoardFromFEN(test.fen2)
+		if err != nil {
+			t.Fatalf("invalid fen %s: %v", test.fen2, err)
+		}
+
+		if b1.key == b2.key {
+			t.Errorf("%s and %s have the same key", test.fen1, test.fen2)
+		}
+	}
+}
+
+func TestZobristUselessEnPassant(t *testing.T) {
+	// No pawn can take on d6, so the position is the same as without it.
+	b1, err := NewBoardFromFEN("4k3/8/8/3p4/8/8/8/4K3 w - d6 0 1")
+	if err != nil {
+		t.Fatalf("invalid fen: %v", err)
+	}
+	b2, err := NewBoardFromFEN("4k3/8/8/3p4/8/8/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("invalid fen: %v", err)
+	}
+
+	if b1.key != b2.key {
+		t.Fatalf("expected the same key")
+	}
+}
@@ -21,8 +21,17 @@ var genes = []Gene{
 	Gene{name: "PiecePositionKing", min: 0.0, max: 1.0},
 	Gene{name: "NbMovesFactor", min: 0.0, max: 1.0},
 	Gene{name: "EndgameNbPieces", min: 2.0, max: 16.0},
+	Gene{name: "HangingPieces", min: 0.0, max: 1.0},
 
 	/* Alpha-beta pruning strategy */
 	Gene{name: "PruneRatio", min: 0.0, max: 0.99},
 	Gene{name: "MinKeptNodes", min: 1.0, max: 5.0},
+	Gene{name: "NullMoveReduction", min: 0.0, max: 4.0},
+	Gene{name: "NullMoveVerification", min: 0.0, max: 8.0},
+	Gene{name: "LMRMoveCount", min: 1.0, max: 16.0},
+	Gene{name: "LMRReduction", min: 0.0, max: 3.0},
+
+	/* Quiescence search */
+	Gene{name: "QuiescenceDepth", min: 0.0, max: 8.0},
+	Gene{name: "DeltaMargin", min: 0.0, max: 10.0},
 }
@@ -0,0 +1,42 @@
+package geneticchess
+
+// SearchLimits bounds the search of a move, which can also be interrupted by
+// its context. A zero SearchLimits searches up to the maximum depth.
+type SearchLimits struct {
+	// Time control of the side to move, ignored by infinite searches.
+	Clock
+
+	// Maximum depth in plies, 0 meaning no limit.
+	Depth uint
+
+	// Maximum number of nodes searched by each thread, 0 meaning no limit.
+	Nodes uint64
+
+	// Searches a checkmate in at most this number of moves, by limiting the
+	// depth accordingly. 0 means no limit.
+	Mate uint
+
+	// Searches until the context is done or the maximum depth is reached,
+	// whatever the time control.
+	Infinite bool
+}
+
+// maxDepth returns the maximum depth in plies, 0 meaning no limit.
+func (l SearchLimits) maxDepth() uint {
+	depth := l.Depth
+
+	if l.Mate > 0 && (depth == 0 || depth > 2*l.Mate-1) {
+		depth = 2*l.Mate - 1
+	}
+
+	return depth
+}
+
+// clock returns the time control of the search.
+func (l SearchLimits) clock() Clock {
+	if l.Infinite {
+		return Clock{}
+	}
+
+	return l.Clock
+}
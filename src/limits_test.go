@@ -0,0 +1,85 @@
+package geneticchess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+func TestSearchLimitsMaxDepth(t *testing.T) {
+	tests := []struct {
+		limits   SearchLimits
+		expected uint
+	}{
+		{SearchLimits{}, 0},
+		{SearchLimits{Depth: 4}, 4},
+		{SearchLimits{Mate: 2}, 3},
+		{SearchLimits{Depth: 2, Mate: 3}, 2},
+		{SearchLimits{Depth: 8, Mate: 3}, 5},
+	}
+
+	for i, test := range tests {
+		if depth := test.limits.maxDepth(); depth != test.expected {
+			t.Errorf("test %d: expected depth %d instead of %d",
+				i, test.expected, depth)
+		}
+	}
+}
+
+func TestSearchLimitsInfinite(t *testing.T) {
+	limits := SearchLimits{Clock: Clock{MoveTime: time.Second}}
+	if limits.clock().MoveTime != time.Second {
+		t.Fatalf("expected the time to think to be kept")
+	}
+
+	limits.Infinite = true
+	if limits.clock() != (Clock{}) {
+		t.Fatalf("expected no time limit for an infinite search")
+	}
+}
+
+func TestSearchNodes(t *testing.T) {
+	b := chess.NewBoard()
+
+	res := NewAI().Search(context.Background(), b, SearchLimits{Nodes: 2000},
+		nil)
+	if res.Move == nil {
+		t.Fatalf("expected a move")
+	}
+
+	// The limit is only checked before searching the moves of a node.
+	if res.Nodes < 2000 || res.Nodes > 4000 {
+		t.Fatalf("expected about 2000 nodes instead of %d", res.Nodes)
+	}
+}
+
+func TestSearchContext(t *testing.T) {
+	b := chess.NewBoard()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The best move so far is returned, even if nothing could be searched.
+	res := NewAI().Search(ctx, b, SearchLimits{Infinite: true}, nil)
+	if res.Move == nil {
+		t.Fatalf("expected a move")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+
+	ai := NewAI()
+	ai.SetThreads(2)
+
+	start := time.Now()
+	res = ai.Search(ctx, b, SearchLimits{Infinite: true}, nil)
+	if res.Move == nil || res.Depth == 0 {
+		t.Fatalf("expected a move from a completed iteration")
+	}
+	if elapsed := time.Now().Sub(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("search took %v instead of about 50ms", elapsed)
+	}
+}
@@ -0,0 +1,135 @@
+package geneticchess
+
+import (
+	"sort"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+// Ranges of the ordering scores, from the first searched moves to the last
+// ones.
+const (
+	orderTTMove  = 1 << 30
+	orderCapture = 1 << 20
+	orderKiller  = 1 << 19
+
+	// History scores are halved when reaching this value, so that they stay
+	// below the killer moves.
+	maxHistory = 1 << 18
+)
+
+// Values used by MVV-LVA, independent of the genes so that the order of the
+// captures does not depend on the evaluation function.
+var mvvLvaValues = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+	chess.King:   20,
+}
+
+// moveOrderer decides in which order the moves of a node are searched, so that
+// the best moves are searched first and cutoffs happen early.
+type moveOrderer struct {
+	// Quiet moves that caused a cutoff, by ply.
+	killers [maxSearchDepth + 1][2]chess.Move
+
+	// Cutoffs caused by quiet moves, by color, origin and destination.
+	history [2][64][64]int
+}
+
+// mvvLva scores a capture or a promotion: most valuable victims first, then
+// least valuable attackers first.
+func mvvLva(b *chess.Board, move *chess.Move) int {
+	score := 0
+
+	if victim := move.Captured(b); victim != chess.Empty {
+		attacker, _ := b.PieceAt(move.From())
+		score = mvvLvaValues[victim]*64 - mvvLvaValues[attacker.Kind()]
+	}
+
+	if move.PromoteTo() != chess.Empty {
+		score += mvvLvaValues[move.PromoteTo()] * 64
+	}
+
+	return score
+}
+
+// colorIndex returns the index of the color in the history table.
+func colorIndex(c chess.Color) int {
+	if c == chess.White {
+		return 0
+	}
+
+	return 1
+}
+
+func (o *moveOrderer) score(b *chess.Board, move *chess.Move, ply int, ttMove *chess.Move) int {
+	if ttMove != nil && move.Equals(ttMove) {
+		return orderTTMove
+	}
+
+	if !move.IsQuiet(b) {
+		return orderCapture + mvvLva(b, move)
+	}
+
+	if ply <= maxSearchDepth {
+		if move.Equals(&o.killers[ply][0]) {
+			return orderKiller + 1
+		}
+		if move.Equals(&o.killers[ply][1]) {
+			return orderKiller
+		}
+	}
+
+	return o.history[colorIndex(b.Turn())][move.From()][move.To()]
+}
+
+// sort orders the nodes of the board, the relative order of moves with the
+// same score being kept.
+func (o *moveOrderer) sort(list PrunableNodes, b *chess.Board, ply int,
+	ttMove *chess.Move) {
+	for i := range list {
+		list[i].order = o.score(b, &list[i].move, ply, ttMove)
+	}
+
+	sort.Stable(nodesByOrder(list))
+}
+
+// cutoff records a quiet move that caused a beta cutoff.
+func (o *moveOrderer) cutoff(b *chess.Board, move *chess.Move, ply int, depth int) {
+	if !move.IsQuiet(b) {
+		return
+	}
+
+	if ply <= maxSearchDepth && !move.Equals(&o.killers[ply][0]) {
+		o.killers[ply][1] = o.killers[ply][0]
+		o.killers[ply][0] = *move
+	}
+
+	history := &o.history[colorIndex(b.Turn())]
+	history[move.From()][move.To()] += depth * depth
+
+	if history[move.From()][move.To()] >= maxHistory {
+		for from := range history {
+			for to := range history[from] {
+				history[from][to] /= 2
+			}
+		}
+	}
+}
+
+type nodesByOrder PrunableNodes
+
+func (nm nodesByOrder) Len() int {
+	return len(nm)
+}
+
+func (nm nodesByOrder) Less(i int, j int) bool {
+	return nm[i].order > nm[j].order
+}
+
+func (nm nodesByOrder) Swap(i int, j int) {
+	nm[i], nm[j] = nm[j], nm[i]
+}
@@ -0,0 +1,100 @@
+package geneticchess
+
+import (
+	"testing"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+func TestOrderingMVVLVA(t *testing.T) {
+	// The pawn and the queen can both take the queen on d5, the queen can
+	// also take the pawn on a5.
+	b, _ := chess.NewBoardFromFEN("4k3/8/8/p2q4/4P3/8/Q7/4K3 w - - 0 1")
+
+	pxq, _ := chess.ParseSAN(b, "exd5")
+	qxq, _ := chess.ParseSAN(b, "Qxd5")
+	qxp, _ := chess.ParseSAN(b, "Qxa5")
+
+	if mvvLva(b, pxq) <= mvvLva(b, qxq) {
+		t.Fatalf("exd5 should be searched before Qxd5")
+	}
+	if mvvLva(b, qxq) <= mvvLva(b, qxp) {
+		t.Fatalf("Qxd5 should be searched before Qxa5")
+	}
+}
+
+func TestOrderingSort(t *testing.T) {
+	b, _ := chess.NewBoardFromFEN("4k3/8/8/p2q4/4P3/8/Q7/4K3 w - - 0 1")
+
+	var o moveOrderer
+	var list PrunableNodes
+	for _, move := range b.GetMoves() {
+		list = append(list, PrunableNode{move: move})
+	}
+
+	ttMove, _ := chess.ParseSAN(b, "Kf2")
+	killer, _ := chess.ParseSAN(b, "Qb2")
+	history, _ := chess.ParseSAN(b, "Qa3")
+
+	o.cutoff(b, killer, 2, 1)
+	o.history[colorIndex(chess.White)][history.From()][history.To()] = 100
+
+	// Captures are not killer moves.
+	capture, _ := chess.ParseSAN(b, "Qxa5")
+	o.cutoff(b, capture, 2, 1)
+
+	o.sort(list, b, 2, ttMove)
+
+	expected := []string{"Kf2", "exd5", "Qxd5", "Qxa5", "Qb2", "Qa3"}
+	for i, san := range expected {
+		if list[i].move.SAN(b) != san {
+			t.Fatalf("expected %s at position %d instead of %s",
+				san, i, list[i].move.SAN(b))
+		}
+	}
+
+	// Killer moves are by ply.
+	o.sort(list, b, 3, nil)
+	if list[3].move.SAN(b) != "Qa3" {
+		t.Fatalf("expected Qa3 instead of %s", list[3].move.SAN(b))
+	}
+}
+
+func TestOrderingKillers(t *testing.T) {
+	b := chess.NewBoard()
+
+	var o moveOrderer
+	moves := b.GetMoves()
+
+	o.cutoff(b, &moves[0], 1, 2)
+	o.cutoff(b, &moves[1], 1, 2)
+	o.cutoff(b, &moves[1], 1, 2)
+
+	if !o.killers[1][0].Equals(&moves[1]) ||
+		!o.killers[1][1].Equals(&moves[0]) {
+		t.Fatalf("unexpected killer moves %v", o.killers[1])
+	}
+
+	if o.history[colorIndex(chess.White)][moves[1].From()][moves[1].To()] != 8 {
+		t.Fatalf("expected a history score of 8")
+	}
+}
+
+func TestOrderingHistoryAging(t *testing.T) {
+	b := chess.NewBoard()
+
+	var o moveOrderer
+	moves := b.GetMoves()
+
+	o.history[colorIndex(chess.White)][moves[1].From()][moves[1].To()] = 10
+	o.history[colorIndex(chess.White)][moves[0].From()][moves[0].To()] = maxHistory - 1
+	o.cutoff(b, &moves[0], 1, 1)
+
+	if o.history[colorIndex(chess.White)][moves[0].From()][moves[0].To()] >=
+		maxHistory {
+		t.Fatalf("history scores should be halved")
+	}
+	if o.history[colorIndex(chess.White)][moves[1].From()][moves[1].To()] != 5 {
+		t.Fatalf("all history scores should be halved")
+	}
+}
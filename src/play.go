@@ -2,21 +2,84 @@ package geneticchess
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
-	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
 )
 
-func Play(file string, timeToThink time.Duration, maxDepth uint) error {
+// parseMove accepts moves in standard algebraic notation ("Nf3"), in long
+// algebraic notation ("g1f3") or as square numbers ("62:45").
+func parseMove(b *chess.Board, text string) (*chess.Move, error) {
+	if move, err := chess.ParseSAN(b, text); err == nil {
+		return move, nil
+	}
+
+	if move, err := chess.ParseUCIMove(b, text); err == nil {
+		return move, nil
+	}
+
+	parts := strings.Split(text, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid move: %s", text)
+	}
+
+	from, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid origin square: %s", parts[0])
+	}
+
+	dest := parts[1]
+
+	if len(dest) == 0 {
+		return nil, fmt.Errorf("invalid destination square")
+	}
+
+	var promotion string
+
+	to, err := strconv.Atoi(dest)
+	if err != nil {
+		promotion = dest[len(dest)-1:]
+		dest = dest[:len(dest)-1]
+		to, err = strconv.Atoi(dest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid destination square: %s", dest)
+		}
+	}
+
+	move := chess.NewPromotion(chess.Position(from), chess.Position(to),
+		chess.StringToPieceType(promotion))
+
+	for _, possibleMove := range b.GetMoves() {
+		if possibleMove.Equals(&move) {
+			return &move, nil
+		}
+	}
+
+	return nil, fmt.Errorf("move %s not allowed", text)
+}
+
+// Play plays against the user on stdin/stdout. Ctrl-C interrupts the search,
+// the best move found so far being played.
+func Play(file string, fen string, limits SearchLimits,
+	hashSize uint, threads uint) error {
 	ai, err := NewAIFromFile(file)
 	if err != nil {
 		return err
 	}
+	ai.SetHashSize(hashSize)
+	ai.SetThreads(threads)
+
+	board, err := chess.NewBoardFromFEN(fen)
+	if err != nil {
+		return err
+	}
 
 	reader := bufio.NewReader(os.Stdin)
-	board := NewBoard()
 
 	for i := 0; ; i++ {
 		if i > 0 {
@@ -32,68 +95,71 @@ func Play(file string, timeToThink time.Duration, maxDepth uint) error {
 		}
 
 		text = strings.TrimSpace(text)
-		parts := strings.Split(text, ":")
-		if len(parts) != 2 {
-			fmt.Printf("invalid move: %s\n", text)
-			continue
-		}
+		if text == "undo" {
+			// Takes back the last move of the ai and the one of the
+			// player.
+			if len(board.Moves()) < 2 {
+				fmt.Println("no move to take back")
+				continue
+			}
 
-		from, err := strconv.Atoi(parts[0])
-		if err != nil {
-			fmt.Printf("invalid origin square: %s\n", parts[0])
+			board.Undo()
+			board.Undo()
 			continue
 		}
+		if text == "draw" {
+			// Claims a draw by threefold repetition or the fifty-move
+			// rule.
+			if state := board.State(); state.IsClaimable() {
+				fmt.Println(state)
+				break
+			}
 
-		dest := parts[1]
-
-		if len(dest) == 0 {
-			fmt.Println("invalid destination square")
+			fmt.Println("no draw to claim")
 			continue
 		}
 
-		var promotion string
-
-		to, err := strconv.Atoi(dest)
+		move, err := parseMove(board, text)
 		if err != nil {
-			promotion = dest[len(dest)-1:]
-			dest = dest[:len(dest)-1]
-			to, err = strconv.Atoi(dest)
-			if err != nil {
-				fmt.Printf("invalid destination square: %s\n", dest)
-				continue
-			}
-		}
-
-		move := &Move{
-			from:      Position(from),
-			to:        Position(to),
-			promoteTo: StringToPieceType(promotion),
-		}
-
-		m := board.GetMoves()
-		ok := false
-		for _, possibleMove := range m {
-			if possibleMove.Equals(move) {
-				ok = true
-			}
-		}
-		if !ok {
-			fmt.Printf("move %s not allowed\n", move.String())
+			fmt.Println(err)
 			continue
 		}
 
 		state := board.Move(move)
-		if state != StatePlaying {
+		if state != chess.StatePlaying && !state.IsClaimable() {
 			fmt.Println(state)
 			break
 		}
 
 		board.Dump()
 		fmt.Println("looking for best move")
-		move = ai.GetBestMove(board, timeToThink, maxDepth)
-		fmt.Printf("best move found: %s\n\n", move.String())
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		res := ai.Search(ctx, board, limits, func(res *SearchResult) {
+			fmt.Println(res.info(board))
+		})
+		stop()
+
+		turn := board.Turn()
+		if state.IsClaimable() && claimsDraw(res, turn) {
+			fmt.Printf("ai claims a %s\n", state)
+			break
+		}
+
+		move = res.Move
+		if res.Depth == 0 {
+			// Interrupted before the end of the first iteration.
+			fmt.Println(res.info(board))
+		}
+		fmt.Printf("best move found: %s\n\n", move.SAN(board))
 		state = board.Move(move)
-		if state != StatePlaying {
+		if state.IsClaimable() {
+			if claimsDraw(res, turn) {
+				fmt.Printf("ai claims a %s\n", state)
+				break
+			}
+
+			fmt.Printf("%s can be claimed with \"draw\"\n", state)
+		} else if state != chess.StatePlaying {
 			fmt.Println(state)
 			break
 		}
@@ -0,0 +1,39 @@
+package geneticchess
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Random generator of the genes, mutations, clone IDs and Chess960 positions,
+// seeded by Seed so that tournaments can be played again.
+var rng = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+
+// Seed initializes the random generator of the tournaments. Tournaments run
+// with the same seed and limited by a number of nodes per move on a single
+// thread play the same games.
+func Seed(seed int64) {
+	rng.Seed(seed)
+}
+
+// lockedSource is a source safe for concurrent use, as the one of the
+// top-level functions of math/rand.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.src.Seed(seed)
+}
@@ -0,0 +1,22 @@
+package geneticchess
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeed(t *testing.T) {
+	mutate := func() *AI {
+		Seed(7)
+		return NewAIRandom().mute(4, 0.5)
+	}
+
+	ai1, ai2 := mutate(), mutate()
+
+	if ai1.String() != ai2.String() {
+		t.Fatalf("expected %s instead of %s", ai1, ai2)
+	}
+	if !reflect.DeepEqual(ai1.Genes, ai2.Genes) {
+		t.Fatalf("expected the same genes: %v, %v", ai1.Genes, ai2.Genes)
+	}
+}
@@ -0,0 +1,627 @@
+package geneticchess
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync/atomic"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+const mateScore = 100000.0
+
+// Used when no maximum depth is given.
+const maxSearchDepth = 64
+
+// Width of the windows that only tell whether a score is above or below a
+// bound.
+const nullWindow = 0.001
+
+type PrunableNode struct {
+	move  chess.Move
+	state chess.State
+
+	// Score relative to the side that played the move.
+	score float64
+
+	// Search order, the highest first.
+	order int
+}
+
+type PrunableNodes []PrunableNode
+
+func (nm PrunableNodes) Len() int {
+	return len(nm)
+}
+
+func (nm PrunableNodes) Less(i int, j int) bool {
+	return nm[i].score > nm[j].score
+}
+
+func (nm PrunableNodes) Swap(i int, j int) {
+	nm[i], nm[j] = nm[j], nm[i]
+}
+
+type searcher struct {
+	ai *AI
+	tt *TranspositionTable
+
+	// The search is aborted when the context is done, or when the hard
+	// time limit or the maximum number of nodes is reached.
+	ctx      context.Context
+	tm       *timeManager
+	maxNodes uint64
+	aborted  bool
+
+	// Updated atomically, the nodes of the helper threads being counted
+	// while they search.
+	nodes uint64
+
+	// Helper threads search one ply deeper every other thread, so that they
+	// do not all search the same depth.
+	depthOffset int
+
+	order moveOrderer
+
+	pruneRatio   float64
+	minKeptNodes uint
+
+	quiescenceDepth int
+	deltaMargin     float64
+
+	nullMoveReduction    int
+	nullMoveVerification int
+
+	lmrMoveCount int
+	lmrReduction int
+
+	// Principal variation of each ply, the variation of the root being
+	// kept as pv once an iteration is completed.
+	plyPV [maxSearchDepth + 1]chess.Moves
+	pv    chess.Moves
+
+	// Last completed depth, and maximum ply reached.
+	depth    int
+	selDepth int
+
+	// Called after each completed iteration with its best move and score,
+	// if not nil.
+	onIteration func(move *chess.Move, score float64)
+}
+
+func (ai *AI) newSearcher(ctx context.Context,
+	limits SearchLimits) *searcher {
+	return &searcher{
+		ai:           ai,
+		tt:           ai.transpositionTable(),
+		ctx:          ctx,
+		tm:           newTimeManager(limits.clock()),
+		maxNodes:     limits.Nodes,
+		pruneRatio:   ai.getGene("PruneRatio"),
+		minKeptNodes: uint(math.Floor(ai.getGene("MinKeptNodes") + 0.5)),
+
+		quiescenceDepth: int(math.Floor(ai.getGene("QuiescenceDepth") + 0.5)),
+		deltaMargin:     ai.getGene("DeltaMargin"),
+
+		nullMoveReduction: int(math.Floor(
+			ai.getGene("NullMoveReduction") + 0.5)),
+		nullMoveVerification: int(math.Floor(
+			ai.getGene("NullMoveVerification") + 0.5)),
+
+		lmrMoveCount: int(math.Floor(ai.getGene("LMRMoveCount") + 0.5)),
+		lmrReduction: int(math.Floor(ai.getGene("LMRReduction") + 0.5)),
+	}
+}
+
+func (s *searcher) checkTime() bool {
+	if s.aborted {
+		return true
+	}
+
+	if s.tm.hardLimitReached() {
+		s.aborted = true
+	}
+	if s.maxNodes > 0 && atomic.LoadUint64(&s.nodes) >= s.maxNodes {
+		s.aborted = true
+	}
+
+	select {
+	case <-s.ctx.Done():
+		s.aborted = true
+	default:
+	}
+
+	return s.aborted
+}
+
+// terminalScore returns the score of a finished game, relative to the side
+// that played the last move.
+func terminalScore(state chess.State, ply int) float64 {
+	switch state {
+	case chess.StateWhiteWins, chess.StateBlackWins:
+		return mateScore - float64(ply)
+	default:
+		return 0
+	}
+}
+
+// children evaluates every move of the board, sorted from the most promising
+// to the least promising one according to the evaluation function.
+func (s *searcher) children(b *chess.Board, ply int) PrunableNodes {
+	var list PrunableNodes
+
+	factor := colorScore(b.Turn())
+
+	for _, move := range b.GetMoves() {
+		state := b.Move(&move)
+
+		node := PrunableNode{
+			move:  move,
+			state: state,
+		}
+
+		if state == chess.StatePlaying {
+			node.score = s.ai.evalPosition(b) * factor
+		} else {
+			node.score = terminalScore(state, ply+1)
+		}
+
+		b.UnmakeMove()
+
+		list = append(list, node)
+	}
+
+	sort.Stable(list)
+
+	return list
+}
+
+// searchedMoves returns the moves of a node to search. Only forward pruning
+// needs every move to be played and evaluated beforehand; without it, the
+// moves are left to the move ordering.
+func (s *searcher) searchedMoves(b *chess.Board, depth int, ply int,
+	ttMove *chess.Move) PrunableNodes {
+	if s.pruneRatio <= 0 || depth <= 1 {
+		moves := b.GetMoves()
+		list := make(PrunableNodes, len(moves))
+		for i := range moves {
+			list[i].move = moves[i]
+		}
+
+		return list
+	}
+
+	all := s.children(b, ply)
+	list := s.ai.pruneNodes(all, s.pruneRatio, s.minKeptNodes)
+
+	if ttMove != nil {
+		// The best move of a previous search is never pruned.
+		if !moveToFront(list, ttMove) {
+			for _, node := range all {
+				if node.move.Equals(ttMove) {
+					list = append(PrunableNodes{node}, list...)
+				}
+			}
+		}
+	}
+
+	return list
+}
+
+// moveToFront moves the given move at the beginning of the list, it returns
+// false if the move is not in the list.
+func moveToFront(list PrunableNodes, move *chess.Move) bool {
+	for i := range list {
+		if list[i].move.Equals(move) {
+			node := list[i]
+			copy(list[1:i+1], list[0:i])
+			list[0] = node
+			return true
+		}
+	}
+
+	return false
+}
+
+// updatePV sets the principal variation of the ply to the move followed by
+// the variation of the next ply.
+func (s *searcher) updatePV(ply int, move *chess.Move) {
+	s.plyPV[ply] = append(append(s.plyPV[ply][:0], *move), s.plyPV[ply+1]...)
+}
+
+// negamax returns the score of the board relative to the side to move, using
+// an alpha-beta window. A null move is not allowed right after another one.
+func (s *searcher) negamax(b *chess.Board, depth int, ply int,
+	alpha float64, beta float64, nullAllowed bool) float64 {
+	atomic.AddUint64(&s.nodes, 1)
+	if ply > s.selDepth {
+		s.selDepth = ply
+	}
+
+	if depth <= 0 {
+		return s.quiescence(b, s.quiescenceDepth, ply, alpha, beta)
+	}
+
+	if s.checkTime() {
+		return 0
+	}
+
+	var ttMove *chess.Move
+	alphaOrig := alpha
+
+	if entry, ok := s.tt.probe(b.Key()); ok {
+		ttMove = &entry.move
+
+		if entry.depth >= depth {
+			score := scoreFromTT(entry.score, ply)
+
+			switch entry.bound {
+			case ttExact:
+				return score
+			case ttLower:
+				alpha = math.Max(alpha, score)
+			case ttUpper:
+				beta = math.Min(beta, score)
+			}
+
+			if alpha >= beta {
+				return score
+			}
+		}
+	}
+
+	inCheck := b.InCheck()
+
+	if nullAllowed && !inCheck {
+		if score, ok := s.nullMove(b, depth, ply, beta); ok {
+			return score
+		}
+	}
+
+	list := s.searchedMoves(b, depth, ply, ttMove)
+	s.order.sort(list, b, ply, ttMove)
+
+	best := -math.MaxFloat64
+	var bestMove chess.Move
+
+	for i, child := range list {
+		var score float64
+
+		s.plyPV[ply+1] = s.plyPV[ply+1][:0]
+
+		reduction := s.lateMoveReduction(b, &child.move, i, depth, inCheck)
+
+		if state := b.Move(&child.move); state != chess.StatePlaying {
+			score = terminalScore(state, ply+1)
+		} else {
+			if b.InCheck() {
+				// Checks are never reduced.
+				reduction = 0
+			}
+
+			if reduction > 0 {
+				score = -s.negamax(b, depth-1-reduction, ply+1,
+					-beta, -alpha, true)
+			}
+			if reduction == 0 || score > alpha {
+				// Moves that turn out better than expected are searched
+				// again at full depth.
+				score = -s.negamax(b, depth-1, ply+1, -beta, -alpha, true)
+			}
+		}
+		b.UnmakeMove()
+
+		if s.aborted {
+			return 0
+		}
+
+		if score > best {
+			best = score
+			bestMove = child.move
+		}
+		if score > alpha {
+			alpha = score
+			s.updatePV(ply, &child.move)
+		}
+		if alpha >= beta {
+			s.order.cutoff(b, &child.move, ply, depth)
+			break
+		}
+	}
+
+	bound := ttExact
+	if best <= alphaOrig {
+		bound = ttUpper
+	} else if best >= beta {
+		bound = ttLower
+	}
+
+	s.tt.store(b.Key(), ttData{
+		score: ttScore(best, ply),
+		move:  bestMove,
+		depth: depth,
+		bound: bound,
+	})
+
+	return best
+}
+
+// nullMove lets the other side play twice: if the position is still good
+// enough to fail high with a reduced search, the node is pruned. It returns
+// false if the node cannot be pruned. Null moves are disabled by a reduction
+// of 0, and in pawn endings where having to move can be a disadvantage
+// (zugzwang). Cutoffs at a depth of at least the verification depth are
+// verified by a reduced search without null moves (0 disables it).
+func (s *searcher) nullMove(b *chess.Board, depth int, ply int,
+	beta float64) (float64, bool) {
+	r := s.nullMoveReduction
+	if r == 0 || depth <= r || !hasPieces(b, b.Turn()) {
+		return 0, false
+	}
+
+	if s.ai.evalPosition(b)*colorScore(b.Turn()) < beta {
+		return 0, false
+	}
+
+	b.MakeNullMove()
+	score := -s.negamax(b, depth-1-r, ply+1, -beta, -beta+nullWindow, false)
+	b.UnmakeNullMove()
+
+	if s.aborted || score < beta {
+		return 0, false
+	}
+
+	if s.nullMoveVerification > 0 && depth >= s.nullMoveVerification {
+		score = s.negamax(b, depth-1-r, ply, beta-nullWindow, beta, false)
+		if s.aborted || score < beta {
+			return 0, false
+		}
+	}
+
+	// Checkmate scores are not proven by a null move.
+	if score > mateScore/2 {
+		score = beta
+	}
+
+	return score, true
+}
+
+// lateMoveReduction returns by how many plies the move is reduced: quiet
+// moves coming late in the move ordering are unlikely to be the best ones and
+// are first searched with a reduced depth. Captures, promotions and moves
+// escaping check are never reduced, neither are the first moves (the move
+// count threshold).
+func (s *searcher) lateMoveReduction(b *chess.Board, move *chess.Move,
+	i int, depth int, inCheck bool) int {
+	if s.lmrReduction == 0 || i < s.lmrMoveCount || depth < 3 || inCheck ||
+		!move.IsQuiet(b) {
+		return 0
+	}
+
+	if depth-1-s.lmrReduction < 1 {
+		return depth - 2
+	}
+
+	return s.lmrReduction
+}
+
+// hasPieces returns true if the color has pieces other than pawns and its
+// king.
+func hasPieces(b *chess.Board, color chess.Color) bool {
+	for pos := chess.Position(0); pos < 64; pos++ {
+		piece, ok := b.PieceAt(pos)
+		if ok && piece.Color() == color &&
+			piece.Kind() != chess.Pawn && piece.Kind() != chess.King {
+			return true
+		}
+	}
+
+	return false
+}
+
+// captures returns the captures and promotions of the board, sorted by
+// MVV-LVA. Captures that cannot raise the score above alpha, even with a
+// margin, are left out (delta pruning, disabled by a margin of 0), as well as
+// the captures losing material according to the static exchange evaluation.
+func (s *searcher) captures(b *chess.Board, standPat float64, alpha float64) chess.Moves {
+	var list PrunableNodes
+
+	for _, move := range b.GetMoves() {
+		if move.IsQuiet(b) {
+			continue
+		}
+
+		if s.deltaMargin > 0 && move.PromoteTo() == chess.Empty {
+			victim := move.Captured(b)
+			if standPat+s.ai.pieceValue(victim)+s.deltaMargin < alpha {
+				continue
+			}
+		}
+
+		if move.PromoteTo() == chess.Empty && b.SEE(&move) < 0 {
+			continue
+		}
+
+		list = append(list, PrunableNode{move: move, order: mvvLva(b, &move)})
+	}
+
+	sort.Stable(nodesByOrder(list))
+
+	moves := make(chess.Moves, len(list))
+	for i := range list {
+		moves[i] = list[i].move
+	}
+
+	return moves
+}
+
+// quiescence only searches captures and promotions, up to the given depth,
+// so that leaves are not evaluated in the middle of an exchange. The score is
+// relative to the side to move.
+func (s *searcher) quiescence(b *chess.Board, depth int, ply int,
+	alpha float64, beta float64) float64 {
+	if ply > s.selDepth {
+		s.selDepth = ply
+	}
+
+	// Stand pat: the side to move is never forced to capture.
+	standPat := s.ai.evalPosition(b) * colorScore(b.Turn())
+	if depth <= 0 || standPat >= beta {
+		return standPat
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	if s.checkTime() {
+		return 0
+	}
+
+	for _, move := range s.captures(b, standPat, alpha) {
+		atomic.AddUint64(&s.nodes, 1)
+
+		var score float64
+
+		state := b.Move(&move)
+		if state != chess.StatePlaying {
+			score = terminalScore(state, ply+1)
+		} else {
+			score = -s.quiescence(b, depth-1, ply+1, -beta, -alpha)
+		}
+		b.UnmakeMove()
+
+		if s.aborted {
+			return 0
+		}
+
+		if score >= beta {
+			return score
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
+
+// iterativeDeepening searches one more ply at a time and returns the best
+// move found by the last completed iteration, or nil if there are no legal
+// moves. The depth and the principal variation of this iteration are kept in
+// the searcher.
+func (s *searcher) iterativeDeepening(b *chess.Board,
+	maxDepth uint) (*chess.Move, float64) {
+	var best *chess.Move
+	var bestScore float64
+
+	if maxDepth == 0 || maxDepth > maxSearchDepth {
+		maxDepth = maxSearchDepth
+	}
+
+	for depth := 1; depth <= int(maxDepth); depth++ {
+		d := depth + s.depthOffset
+		if d > int(maxDepth) {
+			d = int(maxDepth)
+		}
+
+		move, score := s.searchRoot(b, d, best)
+
+		if s.aborted {
+			// The first iteration always gives a move, even if incomplete.
+			if best == nil && move != nil {
+				best, bestScore = move, score
+				s.pv = chess.Moves{*move}
+			}
+			break
+		}
+
+		best, bestScore = move, score
+		s.depth = d
+		s.pv = append(chess.Moves(nil), s.plyPV[0]...)
+
+		if s.onIteration != nil && best != nil {
+			s.onIteration(best, bestScore)
+		}
+
+		if best == nil || math.Abs(bestScore) > mateScore/2 {
+			// No legal moves or forced checkmate.
+			break
+		}
+
+		if s.tm.softLimitReached() {
+			break
+		}
+	}
+
+	return best, bestScore
+}
+
+// searchRoot returns the best move and its score relative to the side to
+// move, or nil if there are no legal moves. The previous best move, if any,
+// is searched first.
+func (s *searcher) searchRoot(b *chess.Board, depth int,
+	previous *chess.Move) (*chess.Move, float64) {
+	// Root moves are never pruned.
+	list := s.children(b, 0)
+	if len(list) == 0 {
+		return nil, 0
+	}
+
+	if previous != nil {
+		moveToFront(list, previous)
+	}
+
+	// Most promising move, in case no move could be searched in time.
+	best := list[0].move
+	bestScore := list[0].score
+	alpha := -math.MaxFloat64
+
+	for i, child := range list {
+		var score float64
+
+		s.plyPV[1] = s.plyPV[1][:0]
+
+		if child.state != chess.StatePlaying {
+			score = child.score
+		} else {
+			b.MakeMove(&child.move)
+			score = -s.negamax(b, depth-1, 1, -math.MaxFloat64, -alpha,
+				true)
+			b.UnmakeMove()
+		}
+
+		if s.aborted {
+			break
+		}
+
+		if i == 0 || score > alpha {
+			alpha = score
+			best = child.move
+			bestScore = score
+			s.updatePV(0, &child.move)
+		}
+	}
+
+	return &best, bestScore
+}
+
+func (ai *AI) pruneNodes(list PrunableNodes,
+	pruneRatio float64, minKeptNodes uint) PrunableNodes {
+	if minKeptNodes == 0 {
+		// Keep at least one node.
+		minKeptNodes = 1
+	}
+
+	sort.Stable(list)
+
+	toKeep := len(list) - int(float64(len(list))*pruneRatio)
+	if toKeep < int(minKeptNodes) {
+		toKeep = int(minKeptNodes)
+	}
+	if toKeep > len(list) {
+		toKeep = len(list)
+	}
+
+	return list[:toKeep]
+}
@@ -0,0 +1,91 @@
+package geneticchess
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+// SearchResult describes the outcome of the search of a move.
+type SearchResult struct {
+	// Best move, nil if there are no legal moves.
+	Move *chess.Move
+
+	// Principal variation: the best move followed by the moves expected
+	// to be played by both sides.
+	PV chess.Moves
+
+	// Score relative to white.
+	Score float64
+
+	// Moves before a checkmate, positive if white mates and negative if
+	// black mates, 0 if no checkmate was found.
+	Mate int
+
+	// Depth of the last completed iteration, and maximum number of plies
+	// searched including the quiescence search.
+	Depth    int
+	SelDepth int
+
+	// Nodes searched by all threads, and per second.
+	Nodes uint64
+	NPS   uint64
+
+	Time time.Duration
+}
+
+// mateIn returns the number of moves before a checkmate, positive if the
+// score is a win and negative if it is a loss, or 0 if it is not a checkmate
+// score.
+func mateIn(score float64) int {
+	if math.Abs(score) <= mateScore/2 {
+		return 0
+	}
+
+	plies := int(mateScore - math.Abs(score))
+	moves := (plies + 1) / 2
+	if score < 0 {
+		return -moves
+	}
+
+	return moves
+}
+
+// uciPV returns the principal variation in UCI notation.
+func (r *SearchResult) uciPV() string {
+	list := make([]string, len(r.PV))
+	for i := range r.PV {
+		list[i] = r.PV[i].UCI()
+	}
+
+	return strings.Join(list, " ")
+}
+
+// sanPV returns the principal variation in standard algebraic notation, the
+// board being the searched position.
+func (r *SearchResult) sanPV(b *chess.Board) string {
+	b = b.Clone()
+	list := make([]string, len(r.PV))
+
+	for i := range r.PV {
+		list[i] = r.PV[i].SAN(b)
+		b.Move(&r.PV[i])
+	}
+
+	return strings.Join(list, " ")
+}
+
+// info describes the search in a single line, as printed in play mode.
+func (r *SearchResult) info(b *chess.Board) string {
+	score := fmt.Sprintf("%+.2f", r.Score)
+	if r.Mate != 0 {
+		score = fmt.Sprintf("mate %d", r.Mate)
+	}
+
+	return fmt.Sprintf("depth %d seldepth %d score %s nodes %d nps %d "+
+		"time %v pv %s", r.Depth, r.SelDepth, score, r.Nodes, r.NPS,
+		r.Time.Truncate(time.Millisecond), r.sanPV(b))
+}
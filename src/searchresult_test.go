@@ -0,0 +1,85 @@
+package geneticchess
+
+import (
+	"testing"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+// Positions with a mate in one, shared by the search tests.
+var mateInOneTests = []struct {
+	fen  string
+	move string
+	mate int
+}{
+	{"6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", 1},
+	{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2",
+		"d8h4", -1},
+}
+
+func TestSearchResult(t *testing.T) {
+	for i, test := range mateInOneTests {
+		b, _ := chess.NewBoardFromFEN(test.fen)
+
+		res := NewAI().GetBestMoveScore(b, 0, 3)
+		if res.uciPV() != test.move || !res.Move.Equals(&res.PV[0]) {
+			t.Errorf("test %d: expected pv %s instead of %s",
+				i, test.move, res.uciPV())
+		}
+		if res.Mate != test.mate {
+			t.Errorf("test %d: expected mate %d instead of %d",
+				i, test.mate, res.Mate)
+		}
+		if res.Depth != 1 || res.Nodes == 0 {
+			t.Errorf("test %d: unexpected depth %d or nodes %d",
+				i, res.Depth, res.Nodes)
+		}
+	}
+}
+
+func TestSearchResultPV(t *testing.T) {
+	b := chess.NewBoard()
+
+	ai := NewAI()
+	ai.SetThreads(2)
+
+	res := ai.GetBestMoveScore(b, 0, 4)
+	if res.Depth != 4 || res.SelDepth < res.Depth {
+		t.Fatalf("unexpected depth %d and seldepth %d",
+			res.Depth, res.SelDepth)
+	}
+	if len(res.PV) == 0 || !res.Move.Equals(&res.PV[0]) {
+		t.Fatalf("pv %v does not start with %s", res.PV, res.Move)
+	}
+	if res.Mate != 0 {
+		t.Fatalf("unexpected mate %d", res.Mate)
+	}
+
+	// Every move of the principal variation is legal.
+	for _, move := range res.PV {
+		if _, err := chess.ParseUCIMove(b, move.UCI()); err != nil {
+			t.Fatalf("illegal pv %v: %v", res.PV, err)
+		}
+		b.Move(&move)
+	}
+}
+
+func TestMateIn(t *testing.T) {
+	tests := []struct {
+		score float64
+		moves int
+	}{
+		{1.5, 0},
+		{mateScore - 1, 1},
+		{mateScore - 3, 2},
+		{-(mateScore - 2), -1},
+		{-(mateScore - 4), -2},
+	}
+
+	for _, test := range tests {
+		if moves := mateIn(test.score); moves != test.moves {
+			t.Errorf("expected %d moves for %f instead of %d",
+				test.moves, test.score, moves)
+		}
+	}
+}
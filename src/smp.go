@@ -0,0 +1,88 @@
+package geneticchess
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+// Maximum number of threads searching a move.
+const MaxThreads = 256
+
+// Search returns the result of the search of the best move within the
+// limits. When the context is done, the search stops and returns the best
+// move found so far.
+//
+// The move is searched by as many threads as set by SetThreads (lazy SMP).
+// The helper threads search the same position on their own copy of the
+// board: they do not share anything but the transposition table, which they
+// fill with results the main thread can use. Only the result of the main
+// thread is returned, the helpers being stopped once it is done, but the
+// nodes of all threads are counted.
+//
+// The info function, if not nil, is called with the result of each completed
+// iteration of the main thread.
+func (ai *AI) Search(ctx context.Context, b *chess.Board,
+	limits SearchLimits, info func(*SearchResult)) *SearchResult {
+	start := time.Now()
+	maxDepth := limits.maxDepth()
+
+	s := ai.newSearcher(ctx, limits)
+	var helpers []*searcher
+
+	result := func(move *chess.Move, score float64) *SearchResult {
+		res := &SearchResult{
+			Move: move,
+			PV:   s.pv,
+
+			// Scores are relative to white outside of the search.
+			Score: score * colorScore(b.Turn()),
+			Mate:  mateIn(score) * int(colorScore(b.Turn())),
+
+			Depth:    s.depth,
+			SelDepth: s.selDepth,
+			Nodes:    atomic.LoadUint64(&s.nodes),
+			Time:     time.Now().Sub(start),
+		}
+
+		for _, helper := range helpers {
+			res.Nodes += atomic.LoadUint64(&helper.nodes)
+		}
+		if res.Time > 0 {
+			res.NPS = uint64(float64(res.Nodes) / res.Time.Seconds())
+		}
+
+		return res
+	}
+
+	if info != nil {
+		s.onIteration = func(move *chess.Move, score float64) {
+			info(result(move, score))
+		}
+	}
+
+	helperCtx, stop := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+
+	for i := uint(1); i < ai.threads; i++ {
+		helper := ai.newSearcher(helperCtx, limits)
+		helper.depthOffset = int(i % 2)
+		helpers = append(helpers, helper)
+
+		wg.Add(1)
+		go func(board *chess.Board) {
+			defer wg.Done()
+			helper.iterativeDeepening(board, maxDepth)
+		}(b.Clone())
+	}
+
+	move, score := s.iterativeDeepening(b, maxDepth)
+
+	stop()
+	wg.Wait()
+
+	return result(move, score)
+}
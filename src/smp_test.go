@@ -0,0 +1,35 @@
+package geneticchess
+
+import (
+	"testing"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+func TestAIThreads(t *testing.T) {
+	for i, test := range mateInOneTests {
+		b, _ := chess.NewBoardFromFEN(test.fen)
+		fen := b.FEN()
+
+		ai := NewAI()
+		ai.SetThreads(4)
+
+		move := ai.GetBestMove(b, 0, 4)
+		if move == nil || move.UCI() != test.move {
+			t.Errorf("test %d: expected %s instead of %v",
+				i, test.move, move)
+		}
+		if b.FEN() != fen {
+			t.Errorf("test %d: board changed by the search", i)
+		}
+	}
+}
+
+func TestAIThreadsClone(t *testing.T) {
+	ai := NewAI()
+	ai.SetThreads(3)
+
+	if ai.mute(1, 0.1).threads != 3 {
+		t.Fatalf("expected children to keep the number of threads")
+	}
+}
@@ -0,0 +1,76 @@
+package geneticchess
+
+import (
+	"time"
+)
+
+// Number of moves assumed to be left in the game when the clock does not say.
+const defaultMovesToGo = 30
+
+// Clock is the time control of the side to move. A zero Clock means there is
+// no time limit.
+type Clock struct {
+	// Fixed time to think for the move, overrides the other fields.
+	MoveTime time.Duration
+
+	Remaining time.Duration
+	Increment time.Duration
+	MovesToGo int
+}
+
+type timeManager struct {
+	start time.Time
+
+	// No new iteration is started after the soft limit, the search is
+	// aborted after the hard limit.
+	soft time.Duration
+	hard time.Duration
+}
+
+func newTimeManager(clock Clock) *timeManager {
+	tm := &timeManager{start: time.Now()}
+
+	if clock.MoveTime > 0 {
+		tm.soft = clock.MoveTime
+		tm.hard = clock.MoveTime
+		return tm
+	}
+
+	if clock.Remaining <= 0 {
+		return tm
+	}
+
+	movesToGo := clock.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+
+	tm.soft = clock.Remaining/time.Duration(movesToGo) + clock.Increment/2
+	tm.hard = tm.soft * 4
+
+	// Never use more than half of the remaining time on a single move.
+	if tm.hard > clock.Remaining/2 {
+		tm.hard = clock.Remaining / 2
+	}
+	if tm.soft > tm.hard {
+		tm.soft = tm.hard
+	}
+	if tm.hard <= 0 {
+		tm.soft = time.Millisecond
+		tm.hard = time.Millisecond
+	}
+
+	return tm
+}
+
+func (tm *timeManager) elapsed() time.Duration {
+	return time.Now().Sub(tm.start)
+}
+
+func (tm *timeManager) softLimitReached() bool {
+	return tm.soft > 0 && tm.elapsed() >= tm.soft
+}
+
+func (tm *timeManager) hardLimitReached() bool {
+	return tm.hard > 0 && tm.elapsed() >= tm.hard
+}
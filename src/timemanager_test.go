@@ -0,0 +1,69 @@
+package geneticchess
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+func TestTimeManagerLimits(t *testing.T) {
+	tests := []struct {
+		clock Clock
+		soft  time.Duration
+		hard  time.Duration
+	}{
+		{Clock{}, 0, 0},
+		{Clock{MoveTime: time.Second, Remaining: time.Minute},
+			time.Second, time.Second},
+		{Clock{Remaining: time.Minute}, 2 * time.Second, 8 * time.Second},
+		{Clock{Remaining: time.Minute, Increment: 2 * time.Second},
+			3 * time.Second, 12 * time.Second},
+		{Clock{Remaining: time.Minute, MovesToGo: 2},
+			30 * time.Second, 30 * time.Second},
+		{Clock{Remaining: time.Second, MovesToGo: 1},
+			500 * time.Millisecond, 500 * time.Millisecond},
+	}
+
+	for i, test := range tests {
+		tm := newTimeManager(test.clock)
+
+		if tm.soft != test.soft || tm.hard != test.hard {
+			t.Errorf("test %d: expected %v/%v instead of %v/%v",
+				i, test.soft, test.hard, tm.soft, tm.hard)
+		}
+	}
+}
+
+func TestTimeManagerNoLimit(t *testing.T) {
+	tm := newTimeManager(Clock{})
+
+	if tm.softLimitReached() || tm.hardLimitReached() {
+		t.Fatalf("a zero clock should not have any limit")
+	}
+}
+
+func TestTimeManagerIterativeDeepening(t *testing.T) {
+	ai := NewAI()
+	b := chess.NewBoard()
+
+	start := time.Now()
+	move := ai.GetBestMove(b, 50*time.Millisecond, 0)
+	if move == nil {
+		t.Fatalf("expected a move")
+	}
+
+	// Only the hard limit and the check for it should be exceeded.
+	if elapsed := time.Now().Sub(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("search took %v instead of about 50ms", elapsed)
+	}
+
+	// A forced checkmate ends the iterations early.
+	b, _ = chess.NewBoardFromFEN("6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1")
+	s := ai.newSearcher(context.Background(), SearchLimits{})
+	move, _ = s.iterativeDeepening(b, 0)
+	if move == nil || !move.Equals(newMove(56, 0)) {
+		t.Fatalf("expected a1a8 instead of %v", move)
+	}
+}
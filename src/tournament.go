@@ -1,16 +1,85 @@
 package geneticchess
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"sort"
 	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
 )
 
 type Game struct {
 	players []*AI
+
+	// Initial position, set to the one given to Tournament.Play when the
+	// game starts if nil.
+	board *chess.Board
+
+	// Record of the game once played.
+	moves chess.Moves
+	state chess.State
+}
+
+// play plays the game from the given position, records it and sends its
+// result. The game is left unfinished if the context is done.
+//
+// Games limited by a number of nodes per move are reproducible: the players
+// search with transposition tables of their own, which do not depend on the
+// other games played at the same time.
+func (g *Game) play(ctx context.Context, start *chess.Board,
+	limits SearchLimits, results chan [2]*Result) {
+	white, black := g.players[0], g.players[1]
+	if limits.Nodes > 0 {
+		white, black = white.withOwnHash(), black.withOwnHash()
+	}
+
+	g.board = start
+	b := start.Clone()
+	g.state = white.playGame(ctx, black, b, chess.White, limits)
+	g.moves = b.Moves()
+
+	if limits.Nodes > 0 {
+		g.players[0].mergeHash(white)
+		g.players[1].mergeHash(black)
+	}
+
+	results <- g.players[0].gameResult(g.players[1], g.state, chess.White)
+}
+
+// White returns the player of the white pieces.
+func (g *Game) White() *AI {
+	return g.players[0]
+}
+
+// Black returns the player of the black pieces.
+func (g *Game) Black() *AI {
+	return g.players[1]
+}
+
+// Start returns a copy of the initial position of the game, nil if it has
+// not been started yet.
+func (g *Game) Start() *chess.Board {
+	if g.board == nil {
+		return nil
+	}
+
+	return g.board.Clone()
+}
+
+// Moves returns the moves played from the initial position, empty if the
+// game has not been played.
+func (g *Game) Moves() chess.Moves {
+	return g.moves
+}
+
+// State returns the outcome of the game, chess.StatePlaying if it has not
+// been played or was aborted.
+func (g *Game) State() chess.State {
+	return g.state
 }
 
 type Tournament struct {
@@ -81,8 +150,26 @@ func NewTournament(players []*AI, nbElites uint, nbChild uint, nbGames uint,
 	return t
 }
 
-func (t *Tournament) Play(timeToThink time.Duration,
-	maxDepth uint, nbParallelGames uint, verbose bool) Results {
+// Games returns the games of the tournament, in the order they are started.
+func (t *Tournament) Games() []*Game {
+	return t.games
+}
+
+// useChess960 starts each pair of games, one with each color, from the same
+// random Chess960 position.
+func (t *Tournament) useChess960() {
+	for i := 0; i+1 < len(t.games); i += 2 {
+		board, _ := chess.NewChess960Board(rng.Intn(960))
+		t.games[i].board = board
+		t.games[i+1].board = board
+	}
+}
+
+// Play plays the games of the tournament and returns the players sorted by
+// score. If the context is done, no more games are started and the games in
+// progress are stopped.
+func (t *Tournament) Play(ctx context.Context, board *chess.Board,
+	limits SearchLimits, nbParallelGames uint, verbose bool) Results {
 	start := time.Now()
 
 	n := 0
@@ -101,15 +188,25 @@ func (t *Tournament) Play(timeToThink time.Duration,
 			fmt.Printf("\rPlaying game %d of %d...", playedGames+1, len(t.games))
 		}
 
-		if n < len(t.games) && playingGames < nbParallelGames {
+		if n < len(t.games) && playingGames < nbParallelGames &&
+			ctx.Err() == nil {
 			game := t.games[n]
-			go game.players[0].Play(game.players[1], White,
-				timeToThink, maxDepth, resChan)
+			start := board
+			if game.board != nil {
+				start = game.board
+			}
+
+			go game.play(ctx, start, limits, resChan)
 			playingGames++
 			n++
 			continue
 		}
 
+		if playingGames == 0 {
+			// Aborted.
+			break
+		}
+
 		res := <-resChan
 		scores[res[0].Player] += res[0].score
 		scores[res[1].Player] += res[1].score
@@ -123,13 +220,24 @@ func (t *Tournament) Play(timeToThink time.Duration,
 	if verbose == true {
 		diff := time.Now().Sub(start)
 		fmt.Println("")
-		fmt.Printf("\nTournament finished in %v (%v/game)\n",
-			diff, diff/time.Duration(len(t.games)))
+		if ctx.Err() != nil {
+			fmt.Printf("\nTournament aborted after %v\n", diff)
+		} else {
+			fmt.Printf("\nTournament finished in %v (%v/game)\n",
+				diff, diff/time.Duration(len(t.games)))
+		}
 	}
 
 	var res Results
 
-	for AI, score := range scores {
+	// Players are listed in a fixed order, for the ties to be broken the same
+	// way by each run.
+	for _, AI := range t.players {
+		score, ok := scores[AI]
+		if !ok {
+			continue
+		}
+
 		res = append(res, Result{
 			Player: AI,
 			score:  score,
@@ -141,8 +249,9 @@ func (t *Tournament) Play(timeToThink time.Duration,
 	if verbose == true {
 		fmt.Println("Results:")
 		for _, r := range res {
-			fmt.Printf("%s: %.2f/%d\n", r.Player.String(),
-				r.score, t.gamesByPlayer)
+			fmt.Printf("%s: %.2f/%d (hash hit rate %.1f%%)\n",
+				r.Player.String(), r.score, t.gamesByPlayer,
+				r.Player.HashHitRate()*100)
 		}
 		fmt.Println("")
 	}
@@ -166,9 +275,19 @@ func (t *Tournament) displayFileUpdated(quiet bool,
 	return true
 }
 
-func RunTournaments(file string, timeToThink time.Duration, maxDepth uint,
+// RunTournaments runs rounds of tournaments, the champion of each round being
+// saved in the file. When the context is done, the tournament in progress is
+// aborted without saving its results.
+func RunTournaments(ctx context.Context, file string, fen string,
+	limits SearchLimits, hashSize uint, threads uint,
 	nbQualified uint, nbChildren uint, nbGames uint, nbMutations uint,
-	mutationSize float64, nbParallelGames uint, rounds uint, quiet bool) error {
+	mutationSize float64, nbParallelGames uint, rounds uint, chess960 bool,
+	quiet bool) error {
+	start, err := chess.NewBoardFromFEN(fen)
+	if err != nil {
+		return err
+	}
+
 	ai, err := NewAIFromFile(file)
 	if err != nil {
 		if !os.IsNotExist(err) {
@@ -177,6 +296,8 @@ func RunTournaments(file string, timeToThink time.Duration, maxDepth uint,
 
 		ai = NewAIRandom()
 	}
+	ai.SetHashSize(hashSize)
+	ai.SetThreads(threads)
 
 	qualified := []*AI{ai}
 
@@ -188,7 +309,15 @@ func RunTournaments(file string, timeToThink time.Duration, maxDepth uint,
 		exChamp := qualified[0]
 		t := NewTournament(qualified, nbQualified,
 			nbChildren, nbGames, nbMutations, mutationSize)
-		res := t.Play(timeToThink, maxDepth, nbParallelGames, !quiet)
+		if chess960 {
+			t.useChess960()
+		}
+
+		res := t.Play(ctx, start, limits, nbParallelGames, !quiet)
+		if ctx.Err() != nil {
+			// The champion of the previous round is already saved.
+			return nil
+		}
 
 		if rounds > 0 && i+1 == rounds {
 			fmt.Printf("Winner: %s", res[0].Player.String())
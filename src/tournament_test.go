@@ -1,8 +1,11 @@
 package geneticchess
 
 import (
+	"context"
 	"testing"
 	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
 )
 
 func getAI() *AI {
@@ -18,8 +21,105 @@ func TestTournament(t *testing.T) {
 		NewAI(), NewAI(),
 	}, 1, 1, 2, 2, 0.5)
 
-	res := tournament.Play(time.Millisecond, 0, 2, false)
+	res := tournament.Play(context.Background(), chess.NewBoard(),
+		SearchLimits{Clock: Clock{MoveTime: time.Millisecond}}, 2, false)
 	if len(res) == 0 {
 		t.Fatalf("tournament results are empty")
 	}
+
+	for i, game := range tournament.Games() {
+		if len(game.Moves()) == 0 || game.State() == chess.StatePlaying {
+			t.Fatalf("game %d is not recorded", i)
+		}
+
+		// The record can be replayed from the initial position.
+		b := game.Start()
+		if b == nil {
+			t.Fatalf("game %d has no initial position", i)
+		}
+		state := chess.StatePlaying
+		for j := range game.Moves() {
+			state = b.Move(&game.Moves()[j])
+		}
+		if state != game.State() {
+			t.Fatalf("game %d: replay ends with %v instead of %v",
+				i, state, game.State())
+		}
+	}
+}
+
+func TestTournamentAbort(t *testing.T) {
+	tournament := NewTournament([]*AI{NewAI()}, 1, 1, 2, 1, 0.5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res := tournament.Play(ctx, chess.NewBoard(), SearchLimits{}, 2, false)
+	if len(res) != 0 {
+		t.Fatalf("expected no results instead of %v", res)
+	}
+
+	// A game in progress is left unfinished.
+	game := tournament.games[0]
+	results := make(chan [2]*Result, 1)
+	game.play(ctx, chess.NewBoard(), SearchLimits{}, results)
+	<-results
+
+	if len(game.moves) != 0 || game.state != chess.StatePlaying {
+		t.Fatalf("expected an unfinished game instead of %v", game.state)
+	}
+}
+
+func TestTournamentReproducible(t *testing.T) {
+	play := func(parallelGames uint) *Tournament {
+		Seed(42)
+
+		tournament := NewTournament([]*AI{NewAI()}, 1, 1, 2, 1, 0.5)
+		tournament.useChess960()
+		tournament.Play(context.Background(), chess.NewBoard(),
+			SearchLimits{Depth: 3, Nodes: 100}, parallelGames, false)
+
+		return tournament
+	}
+
+	t1 := play(1)
+	t2 := play(4)
+
+	for i := range t1.players {
+		if t1.players[i].String() != t2.players[i].String() {
+			t.Fatalf("player %d: %s differs from %s",
+				i, t1.players[i], t2.players[i])
+		}
+	}
+
+	for i := range t1.games {
+		g1, g2 := t1.games[i], t2.games[i]
+
+		if g1.state != g2.state || len(g1.moves) != len(g2.moves) {
+			t.Fatalf("game %d: %v in %d moves differs from %v in %d moves",
+				i, g1.state, len(g1.moves), g2.state, len(g2.moves))
+		}
+		for j := range g1.moves {
+			if !g1.moves[j].Equals(&g2.moves[j]) {
+				t.Fatalf("game %d: move %d differs", i, j)
+			}
+		}
+	}
+}
+
+func TestTournamentChess960(t *testing.T) {
+	tournament := NewTournament([]*AI{NewAI()}, 1, 1, 2, 1, 0.5)
+	tournament.useChess960()
+
+	for i := 0; i < len(tournament.games); i += 2 {
+		white := tournament.games[i]
+		black := tournament.games[i+1]
+
+		if white.board == nil || white.board != black.board {
+			t.Fatalf("games %d and %d should share their start", i, i+1)
+		}
+		if white.players[0] != black.players[1] {
+			t.Fatalf("games %d and %d should swap colors", i, i+1)
+		}
+	}
 }
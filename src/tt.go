@@ -0,0 +1,156 @@
+package geneticchess
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+// Default size of the transposition table, in MB.
+const DefaultHashSize = 16
+
+const (
+	ttExact uint8 = 1
+	ttLower uint8 = 2
+	ttUpper uint8 = 3
+)
+
+// ttEntry is read and written without locks by concurrent searches: the key
+// is stored xored with the data, so that an entry torn by two concurrent
+// writes does not match any key.
+type ttEntry struct {
+	key  uint64
+	data uint64
+}
+
+type ttData struct {
+	score float64
+	move  chess.Move
+	depth int
+	bound uint8
+}
+
+type TranspositionTable struct {
+	entries []ttEntry
+	mask    uint64
+
+	probes uint64
+	hits   uint64
+}
+
+func NewTranspositionTable(sizeMB uint) *TranspositionTable {
+	if sizeMB == 0 {
+		sizeMB = 1
+	}
+
+	// Round down to a power of two so that the key can be masked.
+	n := uint64(sizeMB) * 1024 * 1024 / 16
+	size := uint64(1)
+	for size*2 <= n {
+		size *= 2
+	}
+
+	return &TranspositionTable{
+		entries: make([]ttEntry, size),
+		mask:    size - 1,
+	}
+}
+
+func (d *ttData) pack() uint64 {
+	data := uint64(math.Float32bits(float32(d.score)))
+	data |= uint64(d.move.From()) << 32
+	data |= uint64(d.move.To()) << 38
+	data |= uint64(d.move.PromoteTo()) << 44
+	data |= uint64(d.depth&0xff) << 48
+	data |= uint64(d.bound) << 56
+
+	return data
+}
+
+func unpackTTData(data uint64) ttData {
+	return ttData{
+		score: float64(math.Float32frombits(uint32(data))),
+		move: chess.NewPromotion(
+			chess.Position((data>>32)&0x3f),
+			chess.Position((data>>38)&0x3f),
+			chess.PieceType((data>>44)&0xf),
+		),
+		depth: int((data >> 48) & 0xff),
+		bound: uint8(data >> 56),
+	}
+}
+
+func (tt *TranspositionTable) probe(key uint64) (ttData, bool) {
+	atomic.AddUint64(&tt.probes, 1)
+
+	entry := &tt.entries[key&tt.mask]
+	k := atomic.LoadUint64(&entry.key)
+	data := atomic.LoadUint64(&entry.data)
+
+	if k^data != key || data == 0 {
+		return ttData{}, false
+	}
+
+	atomic.AddUint64(&tt.hits, 1)
+
+	return unpackTTData(data), true
+}
+
+func (tt *TranspositionTable) store(key uint64, d ttData) {
+	entry := &tt.entries[key&tt.mask]
+
+	// Always replace, the newest results being the most useful ones.
+	data := d.pack()
+	atomic.StoreUint64(&entry.data, data)
+	atomic.StoreUint64(&entry.key, key^data)
+}
+
+func (tt *TranspositionTable) Clear() {
+	for i := range tt.entries {
+		atomic.StoreUint64(&tt.entries[i].key, 0)
+		atomic.StoreUint64(&tt.entries[i].data, 0)
+	}
+
+	atomic.StoreUint64(&tt.probes, 0)
+	atomic.StoreUint64(&tt.hits, 0)
+}
+
+// HitRate returns the ratio of successful probes since the last Clear.
+func (tt *TranspositionTable) HitRate() float64 {
+	probes := atomic.LoadUint64(&tt.probes)
+	if probes == 0 {
+		return 0
+	}
+
+	return float64(atomic.LoadUint64(&tt.hits)) / float64(probes)
+}
+
+// addStats counts the probes and hits of another table as probes and hits of
+// the table.
+func (tt *TranspositionTable) addStats(other *TranspositionTable) {
+	atomic.AddUint64(&tt.probes, atomic.LoadUint64(&other.probes))
+	atomic.AddUint64(&tt.hits, atomic.LoadUint64(&other.hits))
+}
+
+// ttScore converts a score to be stored in the table: checkmate scores are
+// made relative to the node instead of the root.
+func ttScore(score float64, ply int) float64 {
+	if score > mateScore/2 {
+		return score + float64(ply)
+	} else if score < -mateScore/2 {
+		return score - float64(ply)
+	}
+
+	return score
+}
+
+func scoreFromTT(score float64, ply int) float64 {
+	if score > mateScore/2 {
+		return score - float64(ply)
+	} else if score < -mateScore/2 {
+		return score + float64(ply)
+	}
+
+	return score
+}
@@ -0,0 +1,77 @@
+package geneticchess
+
+import (
+	"testing"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+func TestTTPack(t *testing.T) {
+	tests := []ttData{
+		{score: 1.5, move: chess.NewMove(52, 36), depth: 3, bound: ttExact},
+		{score: -0.25, move: chess.NewPromotion(8, 0, chess.Knight),
+			depth: 12, bound: ttUpper},
+		{score: mateScore - 3, move: chess.NewMove(63, 62),
+			depth: 1, bound: ttLower},
+	}
+
+	for _, d := range tests {
+		got := unpackTTData(d.pack())
+
+		if got != d {
+			t.Errorf("expected %+v instead of %+v", d, got)
+		}
+	}
+}
+
+func TestTTStoreProbe(t *testing.T) {
+	tt := NewTranspositionTable(1)
+	d := ttData{score: 2, move: chess.NewMove(52, 36), depth: 4,
+		bound: ttExact}
+
+	if _, ok := tt.probe(12345); ok {
+		t.Fatalf("empty table should not have any entry")
+	}
+
+	tt.store(12345, d)
+
+	got, ok := tt.probe(12345)
+	if !ok || got != d {
+		t.Fatalf("expected %+v instead of %+v", d, got)
+	}
+
+	// Same index, different key.
+	if _, ok := tt.probe(12345 + tt.mask + 1); ok {
+		t.Fatalf("probe should fail for a different key")
+	}
+
+	if tt.HitRate() != 1.0/3.0 {
+		t.Fatalf("expected a hit rate of 1/3 instead of %f", tt.HitRate())
+	}
+
+	tt.Clear()
+
+	if _, ok := tt.probe(12345); ok {
+		t.Fatalf("cleared table should not have any entry")
+	}
+}
+
+func TestTTMateScore(t *testing.T) {
+	// Mate found 5 plies from the root, stored at ply 2.
+	score := mateScore - 5
+	stored := ttScore(score, 2)
+
+	if stored != mateScore-3 {
+		t.Fatalf("expected %f instead of %f", mateScore-3, stored)
+	}
+
+	// Same position reached at ply 4.
+	if scoreFromTT(stored, 4) != mateScore-7 {
+		t.Fatalf("expected %f instead of %f", mateScore-7,
+			scoreFromTT(stored, 4))
+	}
+
+	if ttScore(1.5, 10) != 1.5 || scoreFromTT(-1.5, 10) != -1.5 {
+		t.Fatalf("regular scores should not be changed")
+	}
+}
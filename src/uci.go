@@ -0,0 +1,370 @@
+package geneticchess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+type uciEngine struct {
+	ai    *AI
+	board *chess.Board
+
+	// Used when the GUI does not give any limit.
+	limits SearchLimits
+
+	// Castling moves are sent and received as the king taking its own
+	// rook.
+	chess960 bool
+
+	out   io.Writer
+	outMu sync.Mutex
+
+	// Search in progress, closed when the best move has been sent, and
+	// cancellation of its context.
+	searching chan struct{}
+	stop      context.CancelFunc
+	infinite  bool
+}
+
+func UCI(file string, limits SearchLimits, hashSize uint, threads uint) error {
+	ai, err := NewAIFromFile(file)
+	if err != nil {
+		return err
+	}
+	ai.SetHashSize(hashSize)
+	ai.SetThreads(threads)
+
+	return runUCI(ai, os.Stdin, os.Stdout, limits)
+}
+
+func runUCI(ai *AI, in io.Reader, out io.Writer, limits SearchLimits) error {
+	e := &uciEngine{
+		ai:     ai,
+		board:  chess.NewBoard(),
+		limits: limits,
+		out:    out,
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "uci":
+			e.send("id name genetic-chess %s", ai.String())
+			e.send("id author genetic-chess")
+			e.send("option name Hash type spin default %d min 1 max 65536",
+				DefaultHashSize)
+			e.send("option name Threads type spin default 1 min 1 max %d",
+				MaxThreads)
+			e.send("option name UCI_Chess960 type check default false")
+			e.send("uciok")
+
+		case "isready":
+			e.send("readyok")
+
+		case "ucinewgame":
+			e.wait()
+			e.board = chess.NewBoard()
+			e.ai.ClearHash()
+
+		case "setoption":
+			e.wait()
+			err := e.setOption(fields[1:])
+			if err != nil {
+				e.send("info string %v", err)
+			}
+
+		case "position":
+			e.wait()
+			err := e.position(fields[1:])
+			if err != nil {
+				e.send("info string %v", err)
+			}
+
+		case "go":
+			e.wait()
+			e.goSearch(fields[1:])
+
+		case "stop":
+			e.stopSearch()
+
+		case "quit":
+			e.stopSearch()
+			return nil
+
+		default:
+			// Unknown commands (debug, register, ponderhit...) are
+			// ignored as required by the protocol.
+		}
+	}
+
+	e.wait()
+
+	return scanner.Err()
+}
+
+func (e *uciEngine) send(format string, args ...interface{}) {
+	e.outMu.Lock()
+	defer e.outMu.Unlock()
+
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+// wait waits for the best move of the search in progress to be sent.
+// Infinite searches, which only end with "stop", are stopped.
+func (e *uciEngine) wait() {
+	if e.searching == nil {
+		return
+	}
+
+	if e.infinite {
+		e.stop()
+	}
+	<-e.searching
+	e.stop()
+	e.searching = nil
+}
+
+// stopSearch stops the search in progress, the best move found so far being
+// sent.
+func (e *uciEngine) stopSearch() {
+	if e.searching != nil {
+		e.stop()
+	}
+	e.wait()
+}
+
+// setOption handles "setoption name <id> [value <x>]".
+func (e *uciEngine) setOption(args []string) error {
+	if len(args) < 2 || args[0] != "name" {
+		return fmt.Errorf("invalid option")
+	}
+
+	var name, value []string
+
+	for i := 1; i < len(args); i++ {
+		if args[i] == "value" {
+			value = args[i+1:]
+			break
+		}
+		name = append(name, args[i])
+	}
+
+	switch strings.ToLower(strings.Join(name, " ")) {
+	case "hash":
+		size, err := strconv.Atoi(strings.Join(value, ""))
+		if err != nil || size <= 0 {
+			return fmt.Errorf("invalid hash size: %s",
+				strings.Join(value, " "))
+		}
+		e.ai.SetHashSize(uint(size))
+	case "threads":
+		threads, err := strconv.Atoi(strings.Join(value, ""))
+		if err != nil || threads <= 0 || threads > MaxThreads {
+			return fmt.Errorf("invalid number of threads: %s",
+				strings.Join(value, " "))
+		}
+		e.ai.SetThreads(uint(threads))
+	case "uci_chess960":
+		val, err := strconv.ParseBool(strings.Join(value, ""))
+		if err != nil {
+			return fmt.Errorf("invalid UCI_Chess960 value: %s",
+				strings.Join(value, " "))
+		}
+		e.chess960 = val
+	default:
+		return fmt.Errorf("unknown option: %s", strings.Join(name, " "))
+	}
+
+	return nil
+}
+
+func (e *uciEngine) position(args []string) error {
+	var board *chess.Board
+	var err error
+
+	if len(args) == 0 {
+		return fmt.Errorf("missing position")
+	}
+
+	i := 1
+
+	switch args[0] {
+	case "startpos":
+		board = chess.NewBoard()
+	case "fen":
+		for i < len(args) && args[i] != "moves" {
+			i++
+		}
+
+		board, err = chess.NewBoardFromFEN(strings.Join(args[1:i], " "))
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown position type: %s", args[0])
+	}
+
+	if e.chess960 {
+		board.SetChess960(true)
+	}
+
+	if i < len(args) && args[i] == "moves" {
+		for _, str := range args[i+1:] {
+			move, err := chess.ParseUCIMove(board, str)
+			if err != nil {
+				return err
+			}
+
+			board.Move(move)
+		}
+	}
+
+	e.board = board
+
+	return nil
+}
+
+// searchLimits returns the limits given by the arguments of "go", or the
+// default ones if there are none.
+func (e *uciEngine) searchLimits(args []string) SearchLimits {
+	var wtime, btime, winc, binc, movetime time.Duration
+	var movesToGo int
+	var limits SearchLimits
+
+	// The default limits are only used when the GUI does not give any.
+	limited := false
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			limits.Infinite = true
+			limited = true
+			continue
+		}
+
+		if i+1 >= len(args) {
+			break
+		}
+
+		val, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			continue
+		}
+
+		ms := time.Duration(val) * time.Millisecond
+
+		switch args[i] {
+		case "wtime":
+			wtime = ms
+		case "btime":
+			btime = ms
+		case "winc":
+			winc = ms
+		case "binc":
+			binc = ms
+		case "movestogo":
+			movesToGo = val
+		case "movetime":
+			movetime = ms
+		case "depth":
+			limits.Depth = uint(val)
+			limited = true
+		case "nodes":
+			limits.Nodes = uint64(val)
+			limited = true
+		case "mate":
+			limits.Mate = uint(val)
+			limited = true
+		default:
+			continue
+		}
+
+		i++
+	}
+
+	if movetime > 0 {
+		limits.Clock = Clock{MoveTime: movetime}
+		limited = true
+	} else if e.board.Turn() == chess.White && wtime > 0 {
+		limits.Clock = Clock{Remaining: wtime, Increment: winc,
+			MovesToGo: movesToGo}
+		limited = true
+	} else if e.board.Turn() == chess.Black && btime > 0 {
+		limits.Clock = Clock{Remaining: btime, Increment: binc,
+			MovesToGo: movesToGo}
+		limited = true
+	}
+
+	if !limited {
+		return e.limits
+	}
+
+	return limits
+}
+
+func (e *uciEngine) goSearch(args []string) {
+	limits := e.searchLimits(args)
+	board := e.board.Clone()
+
+	ctx, stop := context.WithCancel(context.Background())
+	e.searching = make(chan struct{})
+	e.stop = stop
+	e.infinite = limits.Infinite
+
+	go func(searching chan struct{}) {
+		defer close(searching)
+
+		res := e.ai.Search(ctx, board, limits, func(res *SearchResult) {
+			e.sendInfo(res, board.Turn())
+		})
+		if res.Move == nil {
+			e.send("bestmove 0000")
+			return
+		}
+
+		if res.Depth == 0 {
+			// Stopped before the end of the first iteration.
+			e.sendInfo(res, board.Turn())
+		}
+
+		if limits.Infinite {
+			// The best move must not be sent before "stop", even if the
+			// search ended at the maximum depth.
+			<-ctx.Done()
+		}
+
+		e.send("bestmove %s", res.Move.UCI())
+	}(e.searching)
+}
+
+// sendInfo sends the result of the search, or of one of its iterations.
+func (e *uciEngine) sendInfo(res *SearchResult, turn chess.Color) {
+	e.send("info depth %d seldepth %d score %s nodes %d nps %d time %d pv %s",
+		res.Depth, res.SelDepth, uciScore(res.Score, turn), res.Nodes,
+		res.NPS, res.Time/time.Millisecond, res.uciPV())
+}
+
+// uciScore converts a score relative to white into a score relative to the
+// side to move, in centipawns or in moves before a checkmate.
+func uciScore(score float64, turn chess.Color) string {
+	score *= colorScore(turn)
+
+	if moves := mateIn(score); moves != 0 {
+		return fmt.Sprintf("mate %d", moves)
+	}
+
+	return fmt.Sprintf("cp %d", int(score*100))
+}
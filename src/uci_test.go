@@ -0,0 +1,230 @@
+package geneticchess
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+func TestUCIHandshake(t *testing.T) {
+	var out bytes.Buffer
+
+	in := strings.NewReader("uci\nisready\nquit\n")
+	err := runUCI(NewAI(), in, &out, SearchLimits{Depth: 1})
+	if err != nil {
+		t.Fatalf("uci failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "id name genetic-chess") {
+		t.Fatalf("expected id name instead of %s", lines[0])
+	}
+	if !strings.HasPrefix(lines[2], "option name Hash type spin") {
+		t.Fatalf("expected hash option instead of %s", lines[2])
+	}
+	if !strings.HasPrefix(lines[3], "option name Threads type spin") {
+		t.Fatalf("expected threads option instead of %s", lines[3])
+	}
+	if !strings.HasPrefix(lines[4], "option name UCI_Chess960 type check") {
+		t.Fatalf("expected chess960 option instead of %s", lines[4])
+	}
+	if lines[5] != "uciok" || lines[6] != "readyok" {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+}
+
+func TestUCISetOption(t *testing.T) {
+	var out bytes.Buffer
+
+	ai := NewAI()
+	in := strings.NewReader("setoption name Hash value 2\n" +
+		"setoption name Threads value 3\n" +
+		"setoption name Foo value 1\nisready\nquit\n")
+	err := runUCI(ai, in, &out, SearchLimits{Depth: 1})
+	if err != nil {
+		t.Fatalf("uci failed: %v", err)
+	}
+
+	if ai.hashSize != 2 {
+		t.Fatalf("expected a hash size of 2 instead of %d", ai.hashSize)
+	}
+	if ai.threads != 3 {
+		t.Fatalf("expected 3 threads instead of %d", ai.threads)
+	}
+	if !strings.Contains(out.String(), "info string unknown option: Foo") {
+		t.Fatalf("unknown option not reported: %s", out.String())
+	}
+}
+
+func TestUCIChess960(t *testing.T) {
+	var out bytes.Buffer
+
+	e := &uciEngine{ai: NewAI(), board: chess.NewBoard(), out: &out}
+
+	err := e.setOption(strings.Fields("name UCI_Chess960 value true"))
+	if err != nil || !e.chess960 {
+		t.Fatalf("chess960 not set: %v", err)
+	}
+
+	// Both sides castle king side by taking their h-file rook.
+	err = e.position(strings.Fields("startpos moves e2e4 e7e5 g1f3 g8f6 " +
+		"f1c4 f8c5 e1h1 e8h8"))
+	if err != nil {
+		t.Fatalf("position failed: %v", err)
+	}
+
+	fen := "rnbq1rk1/pppp1ppp/5n2/2b1p3/2B1P3/5N2/PPPP1PPP/RNBQ1RK1 w - - 6 5"
+	if e.board.FEN() != fen {
+		t.Fatalf("expected %s instead of %s", fen, e.board.FEN())
+	}
+}
+
+func TestUCIGo(t *testing.T) {
+	tests := []struct {
+		input    string
+		bestmove string
+	}{
+		// Mate in one.
+		{"position fen 6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1\n" +
+			"go depth 1\n", "bestmove a1a8"},
+		{"position startpos moves f2f3 e7e5 g2g4\ngo depth 2\n",
+			"bestmove d8h4"},
+		{"position startpos moves f2f3 e7e5 g2g4\n" +
+			"go infinite depth 2\nisready\nstop\n", "bestmove d8h4"},
+		{"position fen 7k/5Q2/6K1/8/8/8/8/8 b - - 0 1\ngo depth 1\n",
+			"bestmove 0000"},
+		{"position startpos moves f2f3 e7e5 g2g4\ngo mate 1\n",
+			"bestmove d8h4"},
+		{"position fen 6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1\n" +
+			"go nodes 1000\n", "bestmove a1a8"},
+	}
+
+	for i, test := range tests {
+		var out bytes.Buffer
+
+		err := runUCI(NewAI(), strings.NewReader(test.input), &out,
+			SearchLimits{Depth: 1})
+		if err != nil {
+			t.Fatalf("test %d: uci failed: %v", i, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if lines[len(lines)-1] != test.bestmove {
+			t.Errorf("test %d: expected %s instead of %s",
+				i, test.bestmove, out.String())
+		}
+		if test.bestmove != "bestmove 0000" &&
+			!strings.HasPrefix(lines[len(lines)-2], "info depth ") {
+			t.Errorf("test %d: expected search info instead of %s",
+				i, out.String())
+		}
+	}
+}
+
+func TestUCIInfo(t *testing.T) {
+	var out bytes.Buffer
+
+	in := strings.NewReader("position startpos\ngo depth 3\n")
+	err := runUCI(NewAI(), in, &out, SearchLimits{})
+	if err != nil {
+		t.Fatalf("uci failed: %v", err)
+	}
+
+	// One info line for each iteration, then the best move.
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[3], "bestmove ") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	for i := 0; i < 3; i++ {
+		prefix := fmt.Sprintf("info depth %d seldepth ", i+1)
+		if !strings.HasPrefix(lines[i], prefix) ||
+			!strings.Contains(lines[i], " pv ") {
+			t.Errorf("expected %s... instead of %s", prefix, lines[i])
+		}
+	}
+}
+
+func TestUCISearchLimits(t *testing.T) {
+	defaults := SearchLimits{Clock: Clock{MoveTime: time.Second}, Depth: 3}
+
+	tests := []struct {
+		args     string
+		expected SearchLimits
+	}{
+		{"", defaults},
+		// The default depth does not cap searches with a clock.
+		{"wtime 60000 btime 60000", SearchLimits{
+			Clock: Clock{Remaining: time.Minute}}},
+		{"wtime 60000 btime 60000 depth 5", SearchLimits{
+			Clock: Clock{Remaining: time.Minute}, Depth: 5}},
+		{"movetime 500", SearchLimits{
+			Clock: Clock{MoveTime: 500 * time.Millisecond}}},
+		{"depth 4", SearchLimits{Depth: 4}},
+		{"nodes 1000", SearchLimits{Nodes: 1000}},
+		{"mate 2", SearchLimits{Mate: 2}},
+		{"infinite", SearchLimits{Infinite: true}},
+		// Only the clock of the side to move counts.
+		{"btime 60000", defaults},
+	}
+
+	for i, test := range tests {
+		e := &uciEngine{board: chess.NewBoard(), limits: defaults}
+
+		limits := e.searchLimits(strings.Fields(test.args))
+		if limits != test.expected {
+			t.Errorf("test %d: expected %+v instead of %+v",
+				i, test.expected, limits)
+		}
+	}
+}
+
+func TestUCIStop(t *testing.T) {
+	var out bytes.Buffer
+
+	// Without "stop", the search would go on up to the maximum depth.
+	in := strings.NewReader("position startpos\ngo infinite\nstop\n")
+
+	start := time.Now()
+	err := runUCI(NewAI(), in, &out, SearchLimits{})
+	if err != nil {
+		t.Fatalf("uci failed: %v", err)
+	}
+
+	if elapsed := time.Now().Sub(start); elapsed > time.Second {
+		t.Fatalf("search stopped after %v", elapsed)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if !strings.HasPrefix(lines[len(lines)-1], "bestmove ") ||
+		lines[len(lines)-1] == "bestmove 0000" {
+		t.Fatalf("expected a best move instead of %s", out.String())
+	}
+}
+
+func TestUCIScore(t *testing.T) {
+	tests := []struct {
+		score    float64
+		turn     chess.Color
+		expected string
+	}{
+		{1.5, chess.White, "cp 150"},
+		{1.5, chess.Black, "cp -150"},
+		{99999.0, chess.White, "mate 1"},
+		{-99998.0, chess.White, "mate -1"},
+		{-99997.0, chess.Black, "mate 2"},
+	}
+
+	for _, test := range tests {
+		res := uciScore(test.score, test.turn)
+		if res != test.expected {
+			t.Errorf("expected %s instead of %s", test.expected, res)
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package geneticchess
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+type xboardEngine struct {
+	ai *AI
+
+	board *chess.Board
+
+	// Color played by the engine, ignored in force mode.
+	color chess.Color
+	force bool
+	post  bool
+
+	// Used when the GUI does not give any limit.
+	limits SearchLimits
+
+	// Limits set by "st" and "sd", 0 if not given. They are kept by "new".
+	timeToThink time.Duration
+	maxDepth    uint
+
+	// Clock set by "level" and "time", used when timeToThink is 0. The
+	// time control is kept by "new", which only restarts the clock.
+	movesPerSession int
+	base            time.Duration
+	increment       time.Duration
+	remaining       time.Duration
+
+	out io.Writer
+
+	// Search in progress, closed once its move has been played, and
+	// cancellation of its context. The move of an aborted search is not
+	// played (discard).
+	searching chan struct{}
+	stop      context.CancelFunc
+	discard   int32
+}
+
+func XBoard(file string, limits SearchLimits,
+	hashSize uint, threads uint) error {
+	ai, err := NewAIFromFile(file)
+	if err != nil {
+		return err
+	}
+	ai.SetHashSize(hashSize)
+	ai.SetThreads(threads)
+
+	return runXBoard(ai, os.Stdin, os.Stdout, limits)
+}
+
+func runXBoard(ai *AI, in io.Reader, out io.Writer,
+	limits SearchLimits) error {
+	e := &xboardEngine{
+		ai:     ai,
+		limits: limits,
+		out:    out,
+	}
+	e.newGame()
+
+	return e.run(in)
+}
+
+// run handles the commands read from the input until "quit".
+func (e *xboardEngine) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		args := fields[1:]
+
+		// Commands are handled once the search in progress is over: "?"
+		// stops it, and the commands leaving the game abort it.
+		switch fields[0] {
+		case "?":
+			e.moveNow()
+		case "new", "force", "result", "quit":
+			e.abort()
+		default:
+			e.wait()
+		}
+
+		switch fields[0] {
+		case "protover":
+			e.send("feature myname=\"genetic-chess %s\" usermove=1 "+
+				"setboard=1 ping=1 memory=1 smp=1 sigint=0 sigterm=0 "+
+				"colors=0 done=1", e.ai.String())
+
+		case "new":
+			e.newGame()
+			e.ai.ClearHash()
+
+		case "memory":
+			val, err := strconv.Atoi(strings.Join(args, ""))
+			if err != nil || val <= 0 {
+				e.send("Error (bad size): memory")
+				continue
+			}
+			e.ai.SetHashSize(uint(val))
+
+		case "cores":
+			val, err := strconv.Atoi(strings.Join(args, ""))
+			if err != nil || val <= 0 || val > MaxThreads {
+				e.send("Error (bad number of cores): cores")
+				continue
+			}
+			e.ai.SetThreads(uint(val))
+
+		case "force", "result":
+			e.force = true
+
+		case "draw":
+			// Draw offers are declined, unless the draw can be claimed.
+			if state := e.board.State(); state.IsClaimable() {
+				e.sendResult(state)
+				e.force = true
+			}
+
+		case "go":
+			e.force = false
+			e.color = e.board.Turn()
+			e.think()
+
+		case "playother":
+			e.force = false
+			e.color = !e.board.Turn()
+
+		case "usermove":
+			if len(args) != 1 {
+				e.send("Error (missing move): usermove")
+				continue
+			}
+			e.userMove(args[0])
+
+		case "setboard":
+			board, err := chess.NewBoardFromFEN(strings.Join(args, " "))
+			if err != nil {
+				e.send("tellusererror Illegal position: %v", err)
+				continue
+			}
+			e.board = board
+
+		case "undo":
+			e.undo(1)
+
+		case "remove":
+			e.undo(2)
+
+		case "level":
+			err := e.level(args)
+			if err != nil {
+				e.send("Error (%v): level", err)
+			}
+
+		case "st":
+			val, err := strconv.Atoi(strings.Join(args, ""))
+			if err != nil || val <= 0 {
+				e.send("Error (bad time): st")
+				continue
+			}
+			e.timeToThink = time.Duration(val) * time.Second
+
+		case "sd":
+			val, err := strconv.Atoi(strings.Join(args, ""))
+			if err != nil || val <= 0 {
+				e.send("Error (bad depth): sd")
+				continue
+			}
+			e.maxDepth = uint(val)
+
+		case "time":
+			val, err := strconv.Atoi(strings.Join(args, ""))
+			if err == nil {
+				// Centiseconds
+				e.remaining = time.Duration(val) * 10 * time.Millisecond
+			}
+
+		case "ping":
+			e.send("pong %s", strings.Join(args, " "))
+
+		case "post":
+			e.post = true
+
+		case "nopost":
+			e.post = false
+
+		case "quit":
+			return nil
+
+		case "xboard", "accepted", "rejected", "otim", "random",
+			"hard", "easy", "computer", "name", "rating", "ics", "?":
+			// Nothing to do.
+
+		default:
+			// Old interfaces send moves without "usermove".
+			if _, err := chess.ParseUCIMove(e.board, fields[0]); err == nil {
+				e.userMove(fields[0])
+				continue
+			}
+
+			e.send("Error (unknown command): %s", fields[0])
+		}
+	}
+
+	e.wait()
+
+	return scanner.Err()
+}
+
+func (e *xboardEngine) send(format string, args ...interface{}) {
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+func (e *xboardEngine) newGame() {
+	e.board = chess.NewBoard()
+	e.color = chess.Black
+	e.force = false
+	e.remaining = e.base
+}
+
+func (e *xboardEngine) level(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("expected 3 arguments")
+	}
+
+	mps, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	// The base time is either "<minutes>" or "<minutes>:<seconds>".
+	var base time.Duration
+	parts := strings.Split(args[1], ":")
+	minutes, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return err
+	}
+	base = time.Duration(minutes) * time.Minute
+	if len(parts) == 2 {
+		seconds, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return err
+		}
+		base += time.Duration(seconds) * time.Second
+	}
+
+	inc, err := strconv.ParseFloat(args[2], 64)
+	if err != nil {
+		return err
+	}
+
+	e.movesPerSession = mps
+	e.base = base
+	e.increment = time.Duration(inc * float64(time.Second))
+	e.remaining = base
+	e.timeToThink = 0
+
+	return nil
+}
+
+func (e *xboardEngine) undo(n int) {
+	for i := 0; i < n; i++ {
+		if e.board.Undo() != nil {
+			break
+		}
+	}
+}
+
+func (e *xboardEngine) userMove(str string) {
+	move, err := chess.ParseUCIMove(e.board, str)
+	if err != nil {
+		move, err = chess.ParseSAN(e.board, str)
+	}
+	if err != nil {
+		e.send("Illegal move: %s", str)
+		return
+	}
+
+	if e.play(move) {
+		return
+	}
+
+	if !e.force && e.board.Turn() == e.color {
+		e.think()
+	}
+}
+
+// play makes the move and sends the result if the game is over. Claimable
+// draws do not end the game.
+func (e *xboardEngine) play(move *chess.Move) bool {
+	state := e.board.Move(move)
+	if state == chess.StatePlaying || state.IsClaimable() {
+		return false
+	}
+
+	e.sendResult(state)
+
+	return true
+}
+
+func (e *xboardEngine) sendResult(state chess.State) {
+	switch state {
+	case chess.StateWhiteWins:
+		e.send("1-0 {White mates}")
+	case chess.StateBlackWins:
+		e.send("0-1 {Black mates}")
+	default:
+		e.send("1/2-1/2 {%s}", state.String())
+	}
+}
+
+// searchLimits returns the limits set by the GUI, or the default ones if it
+// did not set any.
+func (e *xboardEngine) searchLimits() SearchLimits {
+	if e.timeToThink == 0 && e.maxDepth == 0 && e.remaining == 0 {
+		return e.limits
+	}
+
+	limits := SearchLimits{
+		Clock: Clock{MoveTime: e.timeToThink},
+		Depth: e.maxDepth,
+	}
+
+	if e.timeToThink == 0 && e.remaining > 0 {
+		limits.Clock = Clock{Remaining: e.remaining, Increment: e.increment}
+
+		if e.movesPerSession > 0 {
+			limits.MovesToGo = e.movesPerSession -
+				(e.board.FullMoveNumber()-1)%e.movesPerSession
+		}
+	}
+
+	return limits
+}
+
+// think searches the move of the engine in the background, the move being
+// played once the search is over.
+func (e *xboardEngine) think() {
+	board := e.board.Clone()
+	limits := e.searchLimits()
+
+	ctx, stop := context.WithCancel(context.Background())
+	e.searching = make(chan struct{})
+	e.stop = stop
+	atomic.StoreInt32(&e.discard, 0)
+
+	go func(searching chan struct{}) {
+		defer close(searching)
+
+		res := e.ai.Search(ctx, board, limits, func(res *SearchResult) {
+			e.sendPost(res, board)
+		})
+		if res.Move == nil || atomic.LoadInt32(&e.discard) != 0 {
+			return
+		}
+
+		// The engine claims the draws it does not expect to win, before
+		// or after its move.
+		claims := claimsDraw(res, board.Turn())
+		if state := board.State(); state.IsClaimable() && claims {
+			e.sendResult(state)
+			return
+		}
+
+		if res.Depth == 0 {
+			// Stopped before the end of the first iteration.
+			e.sendPost(res, board)
+		}
+		e.send("move %s", res.Move.UCI())
+		if e.play(res.Move) {
+			return
+		}
+
+		if state := e.board.State(); state.IsClaimable() && claims {
+			e.sendResult(state)
+		}
+	}(e.searching)
+}
+
+// sendPost sends the result of the search, or of one of its iterations, after
+// "post".
+func (e *xboardEngine) sendPost(res *SearchResult, board *chess.Board) {
+	if !e.post {
+		return
+	}
+
+	// ply score time nodes pv
+	e.send("%d %d %d %d %s", res.Depth, xboardScore(res.Score, board.Turn()),
+		int(res.Time/(10*time.Millisecond)), res.Nodes, res.sanPV(board))
+}
+
+// xboardScore converts a score relative to white into a score relative to the
+// side to move, in centipawns, or 100000 plus the moves before a checkmate
+// (negated when getting mated).
+func xboardScore(score float64, turn chess.Color) int {
+	score *= colorScore(turn)
+
+	if moves := mateIn(score); moves > 0 {
+		return 100000 + moves
+	} else if moves < 0 {
+		return -100000 + moves
+	}
+
+	return int(score * 100)
+}
+
+// wait waits for the search in progress to play its move.
+func (e *xboardEngine) wait() {
+	if e.searching == nil {
+		return
+	}
+
+	<-e.searching
+	e.stop()
+	e.searching = nil
+}
+
+// moveNow stops the search in progress, the best move found so far being
+// played.
+func (e *xboardEngine) moveNow() {
+	if e.searching != nil {
+		e.stop()
+	}
+	e.wait()
+}
+
+// abort stops the search in progress without playing its move.
+func (e *xboardEngine) abort() {
+	if e.searching != nil {
+		atomic.StoreInt32(&e.discard, 1)
+		e.stop()
+	}
+	e.wait()
+}
@@ -0,0 +1,184 @@
+package geneticchess
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clex/genetic-chess/src/chess"
+)
+
+// Moves leading back to the initial position for the third time.
+const repetition = "usermove b1c3\nusermove b8c6\nusermove c3b1\n" +
+	"usermove c6b8\nusermove b1c3\nusermove b8c6\nusermove c3b1\n" +
+	"usermove c6b8\n"
+
+func TestXBoardProtocol(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"xboard\nprotover 2\nping 1\n",
+			[]string{"feature myname=", "pong 1"}},
+		// The engine plays black by default.
+		{"new\nsd 1\nusermove e2e4\n", []string{"move "}},
+		{"new\nsd 1\nforce\nusermove f2f3\nusermove e7e5\n" +
+			"usermove g2g4\ngo\n",
+			[]string{"move d8h4", "0-1 {Black mates}"}},
+		{"new\nsetboard 6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1\nsd 1\ngo\n",
+			[]string{"move a1a8", "1-0 {White mates}"}},
+		{"new\npost\nsetboard 6k1/5ppp/8/8/8/8/8/R3K3 w - - 0 1\n" +
+			"sd 1\ngo\n",
+			[]string{"1 100001 ", "move a1a8", "1-0 {White mates}"}},
+		{"new\nsetboard 8/8/8/8/8/8/8/R3K3 w - - 0 1\n",
+			[]string{"tellusererror Illegal position"}},
+		{"new\nforce\nusermove e2e5\n",
+			[]string{"Illegal move: e2e5"}},
+		{"new\nforce\nusermove e2e4\nundo\nusermove e2e3\nping 2\n",
+			[]string{"pong 2"}},
+		{"new\nforce\nusermove e2e4\nusermove e7e5\nremove\n" +
+			"usermove e7e5\n",
+			[]string{"Illegal move: e7e5"}},
+		{"new\nlevel 40 0:30 0\ntime 3000\nsd 1\nusermove e2e4\n",
+			[]string{"move "}},
+		{"new\ncores 2\nsd 2\nusermove e2e4\n", []string{"move "}},
+		// Threefold repetitions do not end the game, until claimed.
+		{"new\nforce\n" + repetition + "ping 3\n", []string{"pong 3"}},
+		{"new\nforce\n" + repetition + "draw\n",
+			[]string{"1/2-1/2 {draw by repetition}"}},
+		{"new\nforce\nusermove g1f3\ndraw\nping 4\n",
+			[]string{"pong 4"}},
+		{"new\ncores 0\n", []string{"Error (bad number of cores): cores"}},
+	}
+
+	for i, test := range tests {
+		var out bytes.Buffer
+
+		err := runXBoard(NewAI(), strings.NewReader(test.input), &out,
+			SearchLimits{Depth: 1})
+		if err != nil {
+			t.Fatalf("test %d: xboard failed: %v", i, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != len(test.expected) {
+			t.Errorf("test %d: unexpected output: %s", i, out.String())
+			continue
+		}
+
+		for j, line := range lines {
+			if !strings.HasPrefix(line, test.expected[j]) {
+				t.Errorf("test %d: expected %s instead of %s",
+					i, test.expected[j], line)
+			}
+		}
+	}
+}
+
+func TestXBoardInterrupt(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		// Without "?", the search would go on up to the maximum depth.
+		{"new\nforce\nusermove e2e4\ngo\n?\n", []string{"move "}},
+		{"new\nforce\nusermove e2e4\ngo\nforce\nping 1\n",
+			[]string{"pong 1"}},
+		{"new\nforce\nusermove e2e4\ngo\nnew\nping 2\n",
+			[]string{"pong 2"}},
+	}
+
+	for i, test := range tests {
+		var out bytes.Buffer
+
+		// "new" clears the transposition table.
+		ai := NewAI()
+		ai.SetHashSize(1)
+
+		start := time.Now()
+		err := runXBoard(ai, strings.NewReader(test.input), &out,
+			SearchLimits{})
+		if err != nil {
+			t.Fatalf("test %d: xboard failed: %v", i, err)
+		}
+
+		if elapsed := time.Now().Sub(start); elapsed > time.Second {
+			t.Errorf("test %d: search stopped after %v", i, elapsed)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != len(test.expected) {
+			t.Errorf("test %d: unexpected output: %s", i, out.String())
+			continue
+		}
+
+		for j, line := range lines {
+			if !strings.HasPrefix(line, test.expected[j]) {
+				t.Errorf("test %d: expected %s instead of %s",
+					i, test.expected[j], line)
+			}
+		}
+	}
+}
+
+func TestXBoardSearchLimits(t *testing.T) {
+	defaults := SearchLimits{Clock: Clock{MoveTime: time.Second}, Depth: 3}
+
+	tests := []struct {
+		input    string
+		expected SearchLimits
+	}{
+		{"new\n", defaults},
+		// The default depth does not cap searches with a clock.
+		{"new\nlevel 0 1 0\ntime 6000\n", SearchLimits{
+			Clock: Clock{Remaining: time.Minute}}},
+		{"new\nst 2\n", SearchLimits{
+			Clock: Clock{MoveTime: 2 * time.Second}}},
+		{"new\nsd 4\n", SearchLimits{Depth: 4}},
+		// The time controls are kept by new, which restarts the clock.
+		{"level 40 5 0\ntime 100\nnew\n", SearchLimits{
+			Clock: Clock{Remaining: 5 * time.Minute, MovesToGo: 40}}},
+		{"st 2\nnew\n", SearchLimits{
+			Clock: Clock{MoveTime: 2 * time.Second}}},
+		{"sd 4\nnew\n", SearchLimits{Depth: 4}},
+	}
+
+	for i, test := range tests {
+		var out bytes.Buffer
+
+		e := &xboardEngine{ai: NewAI(), limits: defaults, out: &out}
+		e.newGame()
+
+		err := e.run(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("test %d: xboard failed: %v", i, err)
+		}
+
+		if limits := e.searchLimits(); limits != test.expected {
+			t.Errorf("test %d: expected %+v instead of %+v",
+				i, test.expected, limits)
+		}
+	}
+}
+
+func TestXBoardScore(t *testing.T) {
+	tests := []struct {
+		score    float64
+		turn     chess.Color
+		expected int
+	}{
+		{1.5, chess.White, 150},
+		{1.5, chess.Black, -150},
+		{99999.0, chess.White, 100001},
+		{-99998.0, chess.White, -100001},
+		{-99997.0, chess.Black, 100002},
+	}
+
+	for _, test := range tests {
+		res := xboardScore(test.score, test.turn)
+		if res != test.expected {
+			t.Errorf("expected %d instead of %d", test.expected, res)
+		}
+	}
+}